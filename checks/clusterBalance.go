@@ -0,0 +1,175 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// isDataNode reports whether a node holds data, in the given tier if it isn't empty.
+func isDataNode(roles []string, tier string) bool {
+	for _, role := range roles {
+		if role == "data" || (tier == "" && strings.HasPrefix(role, "data")) || role == tier {
+			return true
+		}
+	}
+	return false
+}
+
+func CheckClusterBalance(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "disk"
+	}
+
+	var description string
+	switch metric {
+	case "shards":
+		description = "Shard count"
+	case "disk":
+		description = "Disk usage"
+	case "heap":
+		description = "Heap usage"
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported balance metric %s", metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	switch c.Statistic {
+	case "spread", "stddev", "ratio":
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported statistic %s", c.Statistic)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/jvm,fs", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	shards := make(map[string]int)
+	if metric == "shards" {
+		var allocation []CatAllocation
+		if !getJSON(plugin, fmt.Sprintf("%s/_cat/allocation?format=json", c.ElasticsearchURL), &allocation) {
+			return plugin
+		}
+		for _, row := range allocation {
+			if n, err := strconv.Atoi(row.Shards); err == nil {
+				shards[row.Node] = n
+			}
+		}
+	}
+
+	type nodeValue struct {
+		Name  string
+		Value float64
+	}
+	var values []nodeValue
+	for _, node := range nodeStats.Nodes {
+		if !isDataNode(node.Roles, c.Tier) {
+			continue
+		}
+
+		var value float64
+		switch metric {
+		case "shards":
+			value = float64(shards[node.Name])
+		case "disk":
+			value = float64(helper.CalculateDiskUsagePercentage(node.FS.Total.TotalInBytes, node.FS.Total.FreeInBytes))
+		case "heap":
+			value = float64(node.JVM.Mem.HeapUsedPercent)
+		}
+		values = append(values, nodeValue{Name: node.Name, Value: value})
+	}
+
+	if len(values) < 2 {
+		plugin.ServiceOutput = fmt.Sprintf("OK: %d data nodes, nothing to compare", len(values))
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+		return plugin
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Value < values[j].Value })
+
+	minValue := values[0].Value
+	maxValue := values[len(values)-1].Value
+	median := values[len(values)/2].Value
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1].Value + values[len(values)/2].Value) / 2
+	}
+
+	var sum, squares float64
+	for _, v := range values {
+		sum += v.Value
+	}
+	mean := sum / float64(len(values))
+	for _, v := range values {
+		squares += (v.Value - mean) * (v.Value - mean)
+	}
+	stddev := math.Sqrt(squares / float64(len(values)))
+
+	var ratio float64
+	if median > 0 {
+		ratio = 100 * maxValue / median
+	}
+
+	var skew float64
+	switch c.Statistic {
+	case "spread":
+		skew = maxValue - minValue
+	case "stddev":
+		skew = stddev
+	case "ratio":
+		skew = ratio
+	}
+
+	// Outliers are the nodes more than one standard deviation away from the median.
+	var outliers []string
+	var details []string
+	for i := len(values) - 1; i >= 0; i-- {
+		v := values[i]
+		details = append(details, fmt.Sprintf("%s: %.1f", v.Name, v.Value))
+		if stddev > 0 && math.Abs(v.Value-median) > stddev {
+			outliers = append(outliers, fmt.Sprintf("%s (%.1f)", v.Name, v.Value))
+		}
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	pd := []nagios.PerformanceData{
+		{Label: "spread", Value: fmt.Sprintf("%.2f", maxValue-minValue), Min: "0"},
+		{Label: "stddev", Value: fmt.Sprintf("%.2f", stddev), Min: "0"},
+		{Label: "ratio", Value: fmt.Sprintf("%.2f", ratio), Min: "0", UnitOfMeasurement: "%"},
+		{Label: "median", Value: fmt.Sprintf("%.2f", median)},
+	}
+	for i := range pd {
+		if pd[i].Label == c.Statistic {
+			pd[i].Warn = fmt.Sprintf("%d", c.WarningThreshold)
+			pd[i].Crit = fmt.Sprintf("%d", c.CriticalThreshold)
+		}
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, skew)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s %s of %d data nodes is %.2f",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, c.Statistic, len(values), skew)
+	if plugin.ExitStatusCode != nagios.StateOKExitCode && len(outliers) > 0 {
+		plugin.ServiceOutput += fmt.Sprintf(", outliers: %s", strings.Join(outliers, ", "))
+	}
+
+	return plugin
+}
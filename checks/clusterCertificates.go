@@ -0,0 +1,135 @@
+package checks
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// SSLCertificate represents a certificate of the _ssl/certificates API.
+type SSLCertificate struct {
+	Path      string    `json:"path"`
+	Format    string    `json:"format"`
+	Alias     string    `json:"alias"`
+	SubjectDN string    `json:"subject_dn"`
+	Expiry    time.Time `json:"expiry"`
+}
+
+// peerCertificates returns the certificates presented on the HTTPS
+// Elasticsearch URL. They are only inspected, so the chain isn't verified.
+func peerCertificates(esURL string) ([]SSLCertificate, error) {
+	u, err := url.Parse(esURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "https" {
+		return nil, nil
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{InsecureSkipVerify: true, ServerName: u.Hostname()})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var certificates []SSLCertificate
+	for _, cert := range conn.ConnectionState().PeerCertificates {
+		certificates = append(certificates, SSLCertificate{
+			Path:      address,
+			Format:    "handshake",
+			SubjectDN: cert.Subject.String(),
+			Expiry:    cert.NotAfter,
+		})
+	}
+
+	return certificates, nil
+}
+
+func CheckClusterCertificates(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	// The presented certificate is inspected first, since an expired or
+	// self-signed certificate makes the API request below fail.
+	certificates, err := peerCertificates(c.ElasticsearchURL)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: TLS handshake failed: %v", err)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	// A failed _ssl/certificates request is noted in the long output, the
+	// presented certificates are still graded.
+	var notes []string
+	var configured []SSLCertificate
+	if err := fetchJSON(fmt.Sprintf("%s/_ssl/certificates", c.ElasticsearchURL), &configured); err != nil {
+		notes = append(notes, fmt.Sprintf("_ssl/certificates not checked: %v", err))
+	} else {
+		certificates = append(certificates, configured...)
+	}
+
+	if len(certificates) == 0 {
+		plugin.ServiceOutput = "UNKNOWN: No certificates found"
+		plugin.LongServiceOutput = strings.Join(notes, nagios.CheckOutputEOL)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	sort.SliceStable(certificates, func(i, j int) bool { return certificates[i].Expiry.Before(certificates[j].Expiry) })
+
+	var details []string
+	for _, cert := range certificates {
+		days := time.Until(cert.Expiry).Hours() / 24
+		detail := fmt.Sprintf("%s (%s", cert.SubjectDN, cert.Path)
+		if cert.Alias != "" {
+			detail += fmt.Sprintf(", alias %s", cert.Alias)
+		}
+		details = append(details, fmt.Sprintf("%s): expires %s, in %.0f days", detail, cert.Expiry.Format(time.RFC3339), days))
+	}
+	plugin.LongServiceOutput = strings.Join(append(notes, details...), nagios.CheckOutputEOL)
+
+	soonest := certificates[0]
+	days := time.Until(soonest.Expiry).Hours() / 24
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "min_days_to_expiry",
+			Value: fmt.Sprintf("%.1f", days),
+			Warn:  fmt.Sprintf("%d:", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d:", c.CriticalThreshold),
+		},
+		{Label: "certificates", Value: fmt.Sprintf("%d", len(certificates)), Min: "0"},
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeBelow(c, days)
+	if days < 0 {
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: Certificate %s (%s) expired %.0f days ago", soonest.SubjectDN, soonest.Path, -days)
+		return plugin
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("%s: %d certificates, %s (%s) expires in %.0f days",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), len(certificates), soonest.SubjectDN, soonest.Path, days)
+
+	return plugin
+}
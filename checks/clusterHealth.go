@@ -12,10 +12,11 @@ import (
 )
 
 type ClusterHealthResponse struct {
-	Status           string `json:"status"`
-	ActiveShards     int    `json:"active_shards"`
-	RelocatingShards int    `json:"relocating_shards"`
-	UnassignedShards int    `json:"unassigned_shards"`
+	Status             string `json:"status"`
+	ActiveShards       int    `json:"active_shards"`
+	RelocatingShards   int    `json:"relocating_shards"`
+	InitializingShards int    `json:"initializing_shards"`
+	UnassignedShards   int    `json:"unassigned_shards"`
 }
 
 func CheckClusterHealth(c *config.Config) *nagios.Plugin {
@@ -0,0 +1,289 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+type ILMStatusResponse struct {
+	OperationMode string `json:"operation_mode"`
+}
+
+type ILMExplainResponse struct {
+	Indices map[string]ILMIndex `json:"indices"`
+}
+
+// ILMIndex represents the lifecycle state of a single index.
+type ILMIndex struct {
+	Managed             bool   `json:"managed"`
+	Policy              string `json:"policy"`
+	Phase               string `json:"phase"`
+	Action              string `json:"action"`
+	Step                string `json:"step"`
+	FailedStep          string `json:"failed_step"`
+	LifecycleDateMillis int64  `json:"lifecycle_date_millis"`
+	PhaseTimeMillis     int64  `json:"phase_time_millis"`
+	ActionTimeMillis    int64  `json:"action_time_millis"`
+	StepInfo            struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"step_info"`
+	PhaseExecution struct {
+		PhaseDefinition struct {
+			Actions struct {
+				Rollover *ILMRollover `json:"rollover"`
+			} `json:"actions"`
+		} `json:"phase_definition"`
+	} `json:"phase_execution"`
+}
+
+// ILMRollover represents the conditions of a rollover action.
+type ILMRollover struct {
+	MaxAge              string `json:"max_age"`
+	MaxSize             string `json:"max_size"`
+	MaxDocs             int64  `json:"max_docs"`
+	MaxPrimaryShardSize string `json:"max_primary_shard_size"`
+	MaxPrimaryShardDocs int64  `json:"max_primary_shard_docs"`
+}
+
+const pollIntervalSetting = "indices.lifecycle.poll_interval"
+
+// defaultPollInterval is used if the ILM poll interval can't be read.
+const defaultPollInterval = 10 * time.Minute
+
+// rolloverStats holds the primary shard totals of an index and its largest
+// primary shard, which the rollover conditions are compared with.
+type rolloverStats struct {
+	Docs         int64
+	Size         int64
+	MaxShardDocs int64
+	MaxShardSize int64
+}
+
+// primaryShardStats sums the started primary shards of every index.
+func primaryShardStats(shards []CatShard) map[string]*rolloverStats {
+	stats := make(map[string]*rolloverStats)
+	for _, shard := range shards {
+		if shard.Prirep != "p" || shard.State != "STARTED" {
+			continue
+		}
+
+		docs, _ := strconv.ParseInt(shard.Docs, 10, 64)
+		size, _ := strconv.ParseInt(shard.Store, 10, 64)
+
+		index, ok := stats[shard.Index]
+		if !ok {
+			index = &rolloverStats{}
+			stats[shard.Index] = index
+		}
+		index.Docs += docs
+		index.Size += size
+		if docs > index.MaxShardDocs {
+			index.MaxShardDocs = docs
+		}
+		if size > index.MaxShardSize {
+			index.MaxShardSize = size
+		}
+	}
+	return stats
+}
+
+// ilmPolicyProblems counts the problems of the indices managed by a policy.
+type ilmPolicyProblems struct {
+	Indices int
+	Errors  int
+	Stuck   int
+	Overdue int
+}
+
+// rolloverExceeded returns the first rollover condition the index exceeds.
+// ILM only checks the conditions every poll interval, so the age condition
+// counts once it is exceeded by more than grace.
+func rolloverExceeded(index ILMIndex, stats rolloverStats, grace time.Duration) (string, bool) {
+	rollover := index.PhaseExecution.PhaseDefinition.Actions.Rollover
+	if rollover == nil {
+		return "", false
+	}
+
+	if rollover.MaxAge != "" {
+		maxAge, err := helper.ParseTimeValue(rollover.MaxAge)
+		age := time.Since(time.UnixMilli(index.LifecycleDateMillis))
+		if err == nil && age > maxAge+grace {
+			return fmt.Sprintf("age %s > %s", age.Round(time.Minute), rollover.MaxAge), true
+		}
+	}
+
+	if rollover.MaxDocs > 0 && stats.Docs > rollover.MaxDocs {
+		return fmt.Sprintf("docs %d > %d", stats.Docs, rollover.MaxDocs), true
+	}
+
+	if rollover.MaxPrimaryShardDocs > 0 && stats.MaxShardDocs > rollover.MaxPrimaryShardDocs {
+		return fmt.Sprintf("primary shard docs %d > %d", stats.MaxShardDocs, rollover.MaxPrimaryShardDocs), true
+	}
+
+	if rollover.MaxSize != "" {
+		maxSize, err := helper.ParseByteSize(rollover.MaxSize)
+		if err == nil && stats.Size > maxSize {
+			return fmt.Sprintf("size %d bytes > %s", stats.Size, rollover.MaxSize), true
+		}
+	}
+
+	if rollover.MaxPrimaryShardSize != "" {
+		maxSize, err := helper.ParseByteSize(rollover.MaxPrimaryShardSize)
+		if err == nil && stats.MaxShardSize > maxSize {
+			return fmt.Sprintf("primary shard size %d bytes > %s", stats.MaxShardSize, rollover.MaxPrimaryShardSize), true
+		}
+	}
+
+	return "", false
+}
+
+func CheckClusterILM(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	pattern := c.Index
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	var status ILMStatusResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_ilm/status", c.ElasticsearchURL), &status) {
+		return plugin
+	}
+
+	var explain ILMExplainResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/%s/_ilm/explain", c.ElasticsearchURL, pattern), &explain) {
+		return plugin
+	}
+
+	// Shard stats and the poll interval are only needed for indices waiting
+	// for a rollover.
+	shardStats := make(map[string]*rolloverStats)
+	pollInterval := defaultPollInterval
+	for _, index := range explain.Indices {
+		if index.Managed && index.Action == "rollover" && index.Step == "check-rollover-ready" {
+			var shards []CatShard
+			if !getJSON(plugin, fmt.Sprintf("%s/_cat/shards/%s?format=json&bytes=b", c.ElasticsearchURL, pattern), &shards) {
+				return plugin
+			}
+			shardStats = primaryShardStats(shards)
+
+			var settings ClusterSettingsResponse
+			if !getJSON(plugin, fmt.Sprintf("%s/_cluster/settings?include_defaults=true&flat_settings=true", c.ElasticsearchURL), &settings) {
+				return plugin
+			}
+			if value, ok := settings.Setting(pollIntervalSetting); ok {
+				if interval, err := helper.ParseTimeValue(value); err == nil {
+					pollInterval = interval
+				}
+			}
+			break
+		}
+	}
+
+	names := make([]string, 0, len(explain.Indices))
+	for name := range explain.Indices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	policies := make(map[string]*ilmPolicyProblems)
+	var errorIndices, stuckIndices, overdueIndices, problems []string
+	var managed int
+	for _, name := range names {
+		index := explain.Indices[name]
+		if !index.Managed {
+			continue
+		}
+		managed++
+
+		policy, ok := policies[index.Policy]
+		if !ok {
+			policy = &ilmPolicyProblems{}
+			policies[index.Policy] = policy
+		}
+		policy.Indices++
+
+		phaseAge := now.Sub(time.UnixMilli(index.PhaseTimeMillis))
+		actionAge := now.Sub(time.UnixMilli(index.ActionTimeMillis))
+		waitingForRollover := index.Action == "rollover" && index.Step == "check-rollover-ready"
+
+		switch {
+		case index.Step == "ERROR":
+			policy.Errors++
+			errorIndices = append(errorIndices, name)
+			problems = append(problems, fmt.Sprintf("%s: ERROR in %s/%s/%s: %s", name, index.Phase, index.Action, index.FailedStep, index.StepInfo.Reason))
+		case waitingForRollover:
+			var stats rolloverStats
+			if indexStats, ok := shardStats[name]; ok {
+				stats = *indexStats
+			}
+			if condition, exceeded := rolloverExceeded(index, stats, pollInterval); exceeded {
+				policy.Overdue++
+				overdueIndices = append(overdueIndices, name)
+				problems = append(problems, fmt.Sprintf("%s: not rolled over, %s", name, condition))
+			}
+		case c.PhaseTimeout > 0 && phaseAge > c.PhaseTimeout:
+			policy.Stuck++
+			stuckIndices = append(stuckIndices, name)
+			problems = append(problems, fmt.Sprintf("%s: in phase %s for %s", name, index.Phase, phaseAge.Round(time.Minute)))
+		case c.ActionTimeout > 0 && index.Action != "complete" && actionAge > c.ActionTimeout:
+			policy.Stuck++
+			stuckIndices = append(stuckIndices, name)
+			problems = append(problems, fmt.Sprintf("%s: in action %s/%s for %s", name, index.Phase, index.Action, actionAge.Round(time.Minute)))
+		}
+	}
+
+	policyNames := make([]string, 0, len(policies))
+	for name := range policies {
+		policyNames = append(policyNames, name)
+	}
+	sort.Strings(policyNames)
+
+	var details []string
+	for _, name := range policyNames {
+		policy := policies[name]
+		details = append(details, fmt.Sprintf("policy %s: %d indices, %d errors, %d stuck, %d not rolled over",
+			name, policy.Indices, policy.Errors, policy.Stuck, policy.Overdue))
+	}
+	plugin.LongServiceOutput = strings.Join(append(details, problems...), nagios.CheckOutputEOL)
+
+	pd := []nagios.PerformanceData{
+		{Label: "managed_indices", Value: fmt.Sprintf("%d", managed), Min: "0"},
+		{Label: "error_indices", Value: fmt.Sprintf("%d", len(errorIndices)), Min: "0"},
+		{Label: "stuck_indices", Value: fmt.Sprintf("%d", len(stuckIndices)), Min: "0"},
+		{Label: "not_rolled_over_indices", Value: fmt.Sprintf("%d", len(overdueIndices)), Min: "0"},
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	switch {
+	case status.OperationMode != "RUNNING":
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: ILM is %s", status.OperationMode)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+	case len(errorIndices) > 0:
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: %d indices in ILM ERROR step: %s", len(errorIndices), strings.Join(errorIndices, ", "))
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+	case len(stuckIndices) > 0 || len(overdueIndices) > 0:
+		plugin.ServiceOutput = fmt.Sprintf("WARNING: %d indices stuck, %d indices not rolled over", len(stuckIndices), len(overdueIndices))
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("OK: ILM is running, %d managed indices", managed)
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+	}
+
+	return plugin
+}
@@ -0,0 +1,67 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+type LicenseResponse struct {
+	License struct {
+		Status             string `json:"status"`
+		Type               string `json:"type"`
+		IssuedTo           string `json:"issued_to"`
+		ExpiryDateInMillis int64  `json:"expiry_date_in_millis"`
+	} `json:"license"`
+}
+
+func CheckClusterLicense(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	var license LicenseResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_license", c.ElasticsearchURL), &license) {
+		return plugin
+	}
+
+	status := license.License.Status
+	licenseType := license.License.Type
+
+	if status != "active" {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: %s license is %s", licenseType, status)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	// Basic licenses don't expire and newer versions leave out the expiry date.
+	if license.License.ExpiryDateInMillis == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("OK: %s license is %s and doesn't expire", licenseType, status)
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+		return plugin
+	}
+
+	expiry := time.UnixMilli(license.License.ExpiryDateInMillis)
+	days := time.Until(expiry).Hours() / 24
+
+	daysPerfData := nagios.PerformanceData{
+		Label: "days_to_expiry",
+		Value: fmt.Sprintf("%.1f", days),
+		Warn:  fmt.Sprintf("%d:", c.WarningThreshold),
+		Crit:  fmt.Sprintf("%d:", c.CriticalThreshold),
+	}
+
+	if err := plugin.AddPerfData(false, daysPerfData); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.LongServiceOutput = fmt.Sprintf("Issued to %s, expires %s", license.License.IssuedTo, expiry.Format(time.RFC3339))
+	plugin.ExitStatusCode = gradeBelow(c, days)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s license is %s, expires in %.0f days",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), licenseType, status, days)
+
+	return plugin
+}
@@ -0,0 +1,201 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// coordinatingOnlyRole is the name used for nodes without any roles.
+const coordinatingOnlyRole = "coordinating_only"
+
+// knownRoles lists the roles that can be limited with the roles option.
+var knownRoles = []string{
+	"master",
+	"data_hot",
+	"data_warm",
+	"data_cold",
+	"data_frozen",
+	"ingest",
+	"ml",
+	"transform",
+	coordinatingOnlyRole,
+}
+
+// roleLimit holds the allowed node count for a role, a negative value means no limit.
+type roleLimit struct {
+	Role string
+	Min  int
+	Max  int
+}
+
+// parseRoleLimits parses role limits in the form role=min:max. Either side of
+// the range may be omitted and a single number is treated as a minimum.
+func parseRoleLimits(spec string) ([]roleLimit, error) {
+	var limits []roleLimit
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		role, bounds, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("role limit %q must be in the form role=min:max", item)
+		}
+
+		role = strings.TrimSpace(role)
+		if !isKnownRole(role) {
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+
+		limit := roleLimit{Role: role, Min: -1, Max: -1}
+		minStr, maxStr, hasMax := strings.Cut(bounds, ":")
+		if minStr != "" {
+			n, err := strconv.Atoi(minStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid minimum for role %s: %q", role, minStr)
+			}
+			limit.Min = n
+		}
+		if hasMax && maxStr != "" {
+			n, err := strconv.Atoi(maxStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid maximum for role %s: %q", role, maxStr)
+			}
+			limit.Max = n
+		}
+
+		if limit.Min >= 0 && limit.Max >= 0 && limit.Min > limit.Max {
+			return nil, fmt.Errorf("minimum %d is greater than maximum %d for role %s", limit.Min, limit.Max, role)
+		}
+
+		limits = append(limits, limit)
+	}
+
+	if len(limits) == 0 {
+		return nil, fmt.Errorf("at least one role limit is required")
+	}
+
+	return limits, nil
+}
+
+func isKnownRole(role string) bool {
+	for _, r := range knownRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// isCoordinatingOnly reports whether a node has no roles. Nodes made
+// coordinating only with the legacy node.master, node.data and node.ingest
+// settings keep the remote_cluster_client role.
+func isCoordinatingOnly(roles []string) bool {
+	for _, role := range roles {
+		if role != "remote_cluster_client" {
+			return false
+		}
+	}
+	return true
+}
+
+// countNodeRoles counts the nodes for each role. Nodes with the generic data
+// role hold every data tier and are counted for each of them.
+func countNodeRoles(nodes map[string]NodeInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, node := range nodes {
+		if isCoordinatingOnly(node.Roles) {
+			counts[coordinatingOnlyRole]++
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, role := range node.Roles {
+			if role == "data" {
+				for _, tier := range []string{"data_hot", "data_warm", "data_cold", "data_frozen"} {
+					seen[tier] = true
+				}
+			}
+			seen[role] = true
+		}
+
+		for role := range seen {
+			counts[role]++
+		}
+	}
+
+	return counts
+}
+
+func CheckClusterNodeRoles(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	limits, err := parseRoleLimits(c.Roles)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var nodesInfo ClusterNodesInfoResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes", c.ElasticsearchURL), &nodesInfo) {
+		return plugin
+	}
+
+	counts := countNodeRoles(nodesInfo.Nodes)
+
+	var pd []nagios.PerformanceData
+	var problems []string
+	var details []string
+	for _, limit := range limits {
+		count := counts[limit.Role]
+
+		rolePerfData := nagios.PerformanceData{
+			Label: limit.Role,
+			Value: fmt.Sprintf("%d", count),
+			Min:   "0",
+		}
+		pd = append(pd, rolePerfData)
+
+		switch {
+		case limit.Min >= 0 && count < limit.Min:
+			problems = append(problems, fmt.Sprintf("%s nodes %d < %d", limit.Role, count, limit.Min))
+		case limit.Max >= 0 && count > limit.Max:
+			problems = append(problems, fmt.Sprintf("%s nodes %d > %d", limit.Role, count, limit.Max))
+		}
+	}
+
+	roles := make([]string, 0, len(counts))
+	for role := range counts {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		details = append(details, fmt.Sprintf("%s: %d", role, counts[role]))
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	if len(problems) > 0 {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: %s", strings.Join(problems, ", "))
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("OK: All %d role limits are met", len(limits))
+	plugin.ExitStatusCode = nagios.StateOKExitCode
+
+	return plugin
+}
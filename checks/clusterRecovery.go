@@ -0,0 +1,173 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/state"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// parsePercent parses a _cat percentage such as 80.5%.
+func parsePercent(value string) float64 {
+	percent, _ := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	return percent
+}
+
+func CheckClusterRecovery(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "stuck"
+	}
+
+	switch metric {
+	case "stuck", "active", "duration":
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported recovery metric %s", metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	if metric == "stuck" && c.StateFile == "" {
+		plugin.ServiceOutput = "UNKNOWN: Stuck recoveries can only be detected with a state file"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	// The _cat APIs don't return the cluster name.
+	var root RootResponse
+	if !getJSON(plugin, c.ElasticsearchURL, &root) {
+		return plugin
+	}
+
+	var recoveries []CatRecovery
+	if !getJSON(plugin, fmt.Sprintf("%s/_cat/recovery?active_only=true&format=json&bytes=b&time=ms", c.ElasticsearchURL), &recoveries) {
+		return plugin
+	}
+
+	store, ok := openState(plugin, c)
+	if !ok {
+		return plugin
+	}
+	defer closeState(plugin, store)
+
+	sort.Slice(recoveries, func(i, j int) bool {
+		a, _ := strconv.ParseInt(recoveries[i].Time, 10, 64)
+		b, _ := strconv.ParseInt(recoveries[j].Time, 10, 64)
+		return a > b
+	})
+
+	var details, stuck []string
+	var bytesRecovered, bytesTotal int64
+	var longest time.Duration
+	var longestName string
+	for i, recovery := range recoveries {
+		name := fmt.Sprintf("%s[%s] %s -> %s", recovery.Index, recovery.Shard, recovery.SourceNode, recovery.TargetNode)
+		bytesPercent := parsePercent(recovery.BytesPercent)
+		filesPercent := parsePercent(recovery.FilesPercent)
+
+		millis, _ := strconv.ParseInt(recovery.Time, 10, 64)
+		duration := time.Duration(millis) * time.Millisecond
+		if i == 0 {
+			longest = duration
+			longestName = name
+		}
+
+		recovered, _ := strconv.ParseInt(recovery.BytesRecovered, 10, 64)
+		total, _ := strconv.ParseInt(recovery.BytesTotal, 10, 64)
+		bytesRecovered += recovered
+		bytesTotal += total
+
+		translogOpsPercent := parsePercent(recovery.TranslogOpsPercent)
+		detail := fmt.Sprintf("%s: %s %s, %.1f%% bytes, %.1f%% files, %.1f%% translog operations, running %s",
+			name, recovery.Type, recovery.Stage, bytesPercent, filesPercent, translogOpsPercent, duration.Round(time.Second))
+
+		// A recovery is stuck if it made no progress since the last run. Only
+		// the index and translog stages report progress, the translog stage
+		// in replayed operations once all files are copied.
+		if store != nil && (recovery.Stage == "index" || recovery.Stage == "translog") {
+			progress := map[string]float64{
+				"bytes_percent":        bytesPercent,
+				"files_percent":        filesPercent,
+				"translog_ops_percent": translogOpsPercent,
+				"since":                float64(time.Now().Unix()),
+			}
+
+			key := state.Key(root.ClusterName, "recovery", recovery.Index, recovery.Shard, recovery.TargetNode)
+			previous, ok := store.Get(key)
+			if ok && previous.Values["bytes_percent"] == progress["bytes_percent"] &&
+				previous.Values["files_percent"] == progress["files_percent"] &&
+				previous.Values["translog_ops_percent"] == progress["translog_ops_percent"] {
+				// Keep the time the progress stopped.
+				progress["since"] = previous.Values["since"]
+				stuck = append(stuck, name)
+				detail += fmt.Sprintf(", stuck since %s", time.Unix(int64(progress["since"]), 0).Format(time.RFC3339))
+			}
+			store.Put(key, progress)
+		}
+
+		details = append(details, detail)
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	var bytesPercent float64 = 100
+	if bytesTotal > 0 {
+		bytesPercent = 100 * float64(bytesRecovered) / float64(bytesTotal)
+	}
+
+	var value float64
+	switch metric {
+	case "stuck":
+		value = float64(len(stuck))
+	case "active":
+		value = float64(len(recoveries))
+	case "duration":
+		value = longest.Minutes()
+	}
+
+	pd := []nagios.PerformanceData{
+		{Label: "active_recoveries", Value: fmt.Sprintf("%d", len(recoveries)), Min: "0"},
+		{Label: "stuck_recoveries", Value: fmt.Sprintf("%d", len(stuck)), Min: "0"},
+		{Label: "bytes_percent", Value: fmt.Sprintf("%.1f", bytesPercent), Min: "0", Max: "100", UnitOfMeasurement: "%"},
+		{Label: "longest_recovery_minutes", Value: fmt.Sprintf("%.1f", longest.Minutes()), Min: "0"},
+	}
+
+	labels := map[string]string{
+		"active":   "active_recoveries",
+		"stuck":    "stuck_recoveries",
+		"duration": "longest_recovery_minutes",
+	}
+	for i := range pd {
+		if pd[i].Label == labels[metric] {
+			pd[i].Warn = fmt.Sprintf("%d", c.WarningThreshold)
+			pd[i].Crit = fmt.Sprintf("%d", c.CriticalThreshold)
+		}
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, value)
+	switch {
+	case len(recoveries) == 0:
+		plugin.ServiceOutput = fmt.Sprintf("%s: No active recoveries", nagios.ExitCodeToStateLabel(plugin.ExitStatusCode))
+	case metric == "stuck" && len(stuck) > 0:
+		plugin.ServiceOutput = fmt.Sprintf("%s: %d of %d active recoveries stuck: %s",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), len(stuck), len(recoveries), strings.Join(stuck, ", "))
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("%s: %d active recoveries, %.1f%% bytes recovered, longest %s running %s",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), len(recoveries), bytesPercent, longestName, longest.Round(time.Second))
+	}
+
+	return plugin
+}
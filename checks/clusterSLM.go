@@ -0,0 +1,116 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+type SLMStatusResponse struct {
+	OperationMode string `json:"operation_mode"`
+}
+
+// SLMPolicy represents a snapshot lifecycle management policy with its last runs.
+type SLMPolicy struct {
+	LastSuccess *SLMInvocation `json:"last_success"`
+	LastFailure *SLMInvocation `json:"last_failure"`
+}
+
+// SLMInvocation represents a single run of a snapshot lifecycle management policy.
+type SLMInvocation struct {
+	SnapshotName string `json:"snapshot_name"`
+	Time         int64  `json:"time"`
+	Details      string `json:"details"`
+}
+
+func CheckClusterSLM(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	var status SLMStatusResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_slm/status", c.ElasticsearchURL), &status) {
+		return plugin
+	}
+
+	var policies map[string]SLMPolicy
+	if !getJSON(plugin, fmt.Sprintf("%s/_slm/policy", c.ElasticsearchURL), &policies) {
+		return plugin
+	}
+
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var failed []string
+	var maxAge float64
+	var oldest string
+	for _, name := range names {
+		policy := policies[name]
+
+		if policy.LastSuccess == nil {
+			if policy.LastFailure != nil {
+				failed = append(failed, fmt.Sprintf("%s (%s)", name, policy.LastFailure.Details))
+				details = append(details, fmt.Sprintf("%s: never succeeded, last failure: %s", name, policy.LastFailure.Details))
+			} else {
+				details = append(details, fmt.Sprintf("%s: not run yet", name))
+			}
+			continue
+		}
+
+		age := time.Since(time.UnixMilli(policy.LastSuccess.Time)).Hours()
+		if oldest == "" || age > maxAge {
+			maxAge = age
+			oldest = name
+		}
+
+		if policy.LastFailure != nil && policy.LastFailure.Time > policy.LastSuccess.Time {
+			failed = append(failed, fmt.Sprintf("%s (%s)", name, policy.LastFailure.Details))
+			details = append(details, fmt.Sprintf("%s: last success %.1fh ago, last failure: %s", name, age, policy.LastFailure.Details))
+		} else {
+			details = append(details, fmt.Sprintf("%s: last success %.1fh ago (%s)", name, age, policy.LastSuccess.SnapshotName))
+		}
+
+		agePerfData := nagios.PerformanceData{
+			Label: perfLabel(fmt.Sprintf("%s_age_hours", name)),
+			Value: fmt.Sprintf("%.1f", age),
+			Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+			Min:   "0",
+		}
+		pd = append(pd, agePerfData)
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	switch {
+	case status.OperationMode != "RUNNING":
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: SLM is %s", status.OperationMode)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+	case len(failed) > 0:
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: SLM policies failing: %s", strings.Join(failed, ", "))
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+	case oldest == "":
+		plugin.ServiceOutput = fmt.Sprintf("OK: SLM is running, %d policies without successful runs", len(names))
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+	default:
+		plugin.ExitStatusCode = gradeAbove(c, maxAge)
+		plugin.ServiceOutput = fmt.Sprintf("%s: SLM is running, last success of policy %s is %.1fh old",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), oldest, maxAge)
+	}
+
+	return plugin
+}
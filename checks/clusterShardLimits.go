@@ -0,0 +1,302 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+const (
+	maxShardsPerNodeSetting       = "cluster.max_shards_per_node"
+	maxShardsPerFrozenNodeSetting = "cluster.max_shards_per_node.frozen"
+	partialSnapshotSetting        = "index.store.snapshot.partial"
+)
+
+// shardLimitGroup is a group of nodes sharing a shard limit, the frozen tier
+// has its own limit since Elasticsearch 7.12.
+type shardLimitGroup struct {
+	Name         string
+	PerNodeLimit int
+	Nodes        int
+	Shards       int
+}
+
+// Usage returns the percentage of the group limit in use.
+func (g shardLimitGroup) Usage() float64 {
+	if g.Nodes == 0 || g.PerNodeLimit == 0 {
+		return 0
+	}
+	return 100 * float64(g.Shards) / float64(g.PerNodeLimit*g.Nodes)
+}
+
+// isFrozenNode reports whether a node holds the frozen tier only, it counts
+// towards the frozen shard limit instead of the normal one.
+func isFrozenNode(roles []string) bool {
+	frozen := false
+	for _, role := range roles {
+		switch {
+		case role == "data_frozen":
+			frozen = true
+		case strings.HasPrefix(role, "data"):
+			return false
+		}
+	}
+	return frozen
+}
+
+func CheckClusterShardLimits(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "usage"
+	}
+
+	switch metric {
+	case "usage", "skew":
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported shard limits metric %s", metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var settings ClusterSettingsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_cluster/settings?include_defaults=true&flat_settings=true", c.ElasticsearchURL), &settings) {
+		return plugin
+	}
+
+	var nodesInfo ClusterNodesInfoResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes", c.ElasticsearchURL), &nodesInfo) {
+		return plugin
+	}
+
+	var indices []CatIndex
+	if !getJSON(plugin, fmt.Sprintf("%s/_cat/indices?format=json", c.ElasticsearchURL), &indices) {
+		return plugin
+	}
+
+	var indexSettings IndexSettingsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_all/_settings/%s?flat_settings=true", c.ElasticsearchURL, partialSnapshotSetting), &indexSettings) {
+		return plugin
+	}
+
+	var allocation []CatAllocation
+	if !getJSON(plugin, fmt.Sprintf("%s/_cat/allocation?format=json", c.ElasticsearchURL), &allocation) {
+		return plugin
+	}
+
+	normal := shardLimitGroup{Name: "normal"}
+	frozen := shardLimitGroup{Name: "frozen"}
+
+	for _, limit := range []struct {
+		Group   *shardLimitGroup
+		Setting string
+	}{
+		{&normal, maxShardsPerNodeSetting},
+		{&frozen, maxShardsPerFrozenNodeSetting},
+	} {
+		value, ok := settings.Setting(limit.Setting)
+		if !ok {
+			// Clusters before 7.12 have no frozen limit and no frozen nodes.
+			if limit.Group == &frozen {
+				continue
+			}
+			plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Setting %s not found", limit.Setting)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			return plugin
+		}
+
+		perNodeLimit, err := strconv.Atoi(value)
+		if err != nil || perNodeLimit <= 0 {
+			plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Invalid %s value %q", limit.Setting, value)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			return plugin
+		}
+		limit.Group.PerNodeLimit = perNodeLimit
+	}
+
+	frozenNodes := make(map[string]bool)
+	for _, node := range nodesInfo.Nodes {
+		switch {
+		case isFrozenNode(node.Roles):
+			frozen.Nodes++
+			frozenNodes[node.Name] = true
+		case isDataNode(node.Roles, ""):
+			normal.Nodes++
+		}
+	}
+
+	// Only shards of open indices count towards the limit, including
+	// unassigned ones. Partially mounted searchable snapshots count towards
+	// the frozen limit.
+	for _, index := range indices {
+		if index.Status != "open" {
+			continue
+		}
+
+		primaries, _ := strconv.Atoi(index.Pri)
+		replicas, _ := strconv.Atoi(index.Rep)
+		shards := primaries * (1 + replicas)
+
+		if fmt.Sprintf("%v", indexSettings[index.Index].Settings[partialSnapshotSetting]) == "true" {
+			frozen.Shards += shards
+		} else {
+			normal.Shards += shards
+		}
+	}
+
+	sort.Slice(allocation, func(i, j int) bool { return allocation[i].Node < allocation[j].Node })
+
+	groups := []shardLimitGroup{normal}
+	if frozen.Nodes > 0 || frozen.Shards > 0 {
+		groups = append(groups, frozen)
+	}
+
+	var pd []nagios.PerformanceData
+	for _, group := range groups {
+		label := "shard_limit_usage"
+		shardsLabel := "shards"
+		if group.Name == "frozen" {
+			label = "frozen_shard_limit_usage"
+			shardsLabel = "frozen_shards"
+		}
+
+		usagePerfData := nagios.PerformanceData{
+			Label:             label,
+			Value:             fmt.Sprintf("%.1f", group.Usage()),
+			Min:               "0",
+			Max:               "100",
+			UnitOfMeasurement: "%",
+		}
+		if metric == "usage" {
+			usagePerfData.Warn = fmt.Sprintf("%d", c.WarningThreshold)
+			usagePerfData.Crit = fmt.Sprintf("%d", c.CriticalThreshold)
+		}
+		pd = append(pd, usagePerfData, nagios.PerformanceData{
+			Label: shardsLabel,
+			Value: fmt.Sprintf("%d", group.Shards),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", group.PerNodeLimit*group.Nodes),
+		})
+	}
+
+	// Frozen nodes are graded against their own limit and left out of the
+	// skew, they hold far more shards than the other data nodes by design.
+	var details []string
+	var maxNodeShards, minNodeShards int
+	var maxNodeUsage float64
+	var maxNode, minNode, maxUsageNode string
+	var nodes int
+	for _, row := range allocation {
+		if row.Node == "UNASSIGNED" {
+			continue
+		}
+
+		if !nodeSelected(c, row.Node, row.IP) {
+			continue
+		}
+
+		nodeShards, err := strconv.Atoi(row.Shards)
+		if err != nil {
+			continue
+		}
+
+		perNodeLimit := normal.PerNodeLimit
+		if frozenNodes[row.Node] {
+			perNodeLimit = frozen.PerNodeLimit
+		}
+
+		if perNodeLimit > 0 {
+			usage := 100 * float64(nodeShards) / float64(perNodeLimit)
+			if maxUsageNode == "" || usage > maxNodeUsage {
+				maxNodeUsage = usage
+				maxUsageNode = row.Node
+			}
+		}
+
+		details = append(details, fmt.Sprintf("%s: %d of %d shards", row.Node, nodeShards, perNodeLimit))
+		pd = append(pd, nagios.PerformanceData{
+			Label: fmt.Sprintf("%s_shards", row.Node),
+			Value: fmt.Sprintf("%d", nodeShards),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", perNodeLimit),
+		})
+
+		if frozenNodes[row.Node] {
+			continue
+		}
+
+		nodes++
+		if nodes == 1 || nodeShards > maxNodeShards {
+			maxNodeShards = nodeShards
+			maxNode = row.Node
+		}
+		if nodes == 1 || nodeShards < minNodeShards {
+			minNodeShards = nodeShards
+			minNode = row.Node
+		}
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	skew := maxNodeShards - minNodeShards
+	if nodes > 0 {
+		skewPerfData := nagios.PerformanceData{Label: "node_shards_skew", Value: fmt.Sprintf("%d", skew), Min: "0"}
+		if metric == "skew" {
+			skewPerfData.Warn = fmt.Sprintf("%d", c.WarningThreshold)
+			skewPerfData.Crit = fmt.Sprintf("%d", c.CriticalThreshold)
+		}
+		pd = append(pd, skewPerfData)
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	if metric == "skew" {
+		if nodes == 0 {
+			plugin.ServiceOutput = "UNKNOWN: No nodes with shards found"
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			return plugin
+		}
+
+		plugin.ExitStatusCode = gradeAbove(c, float64(skew))
+		plugin.ServiceOutput = fmt.Sprintf("%s: Shard skew of %d nodes is %d, %d shards on node %s, %d shards on node %s",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), nodes, skew, maxNodeShards, maxNode, minNodeShards, minNode)
+		return plugin
+	}
+
+	worst := groups[0]
+	for _, group := range groups[1:] {
+		if group.Usage() > worst.Usage() {
+			worst = group
+		}
+	}
+
+	// A single node can hit its share of the limit before the cluster does.
+	if maxUsageNode != "" && gradeAbove(c, maxNodeUsage) > gradeAbove(c, worst.Usage()) {
+		plugin.ExitStatusCode = gradeAbove(c, maxNodeUsage)
+		plugin.ServiceOutput = fmt.Sprintf("%s: Node %s uses %.1f%% of its shards per node limit, %s limit %d of %d shards",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), maxUsageNode, maxNodeUsage, worst.Name, worst.Shards, worst.PerNodeLimit*worst.Nodes)
+		return plugin
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, worst.Usage())
+	plugin.ServiceOutput = fmt.Sprintf("%s: %d of %d shards of open indices used (%.1f%%)",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), normal.Shards, normal.PerNodeLimit*normal.Nodes, normal.Usage())
+	if len(groups) > 1 {
+		plugin.ServiceOutput += fmt.Sprintf(", frozen %d of %d (%.1f%%)", frozen.Shards, frozen.PerNodeLimit*frozen.Nodes, frozen.Usage())
+	}
+	if maxNode != "" {
+		plugin.ServiceOutput += fmt.Sprintf(", max %d shards on node %s", maxNodeShards, maxNode)
+	}
+
+	return plugin
+}
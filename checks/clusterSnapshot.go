@@ -0,0 +1,130 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"sort"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+type SnapshotsResponse struct {
+	Snapshots []SnapshotInfo `json:"snapshots"`
+}
+
+// SnapshotInfo represents a snapshot in a snapshot repository.
+type SnapshotInfo struct {
+	Snapshot          string `json:"snapshot"`
+	State             string `json:"state"`
+	StartTimeInMillis int64  `json:"start_time_in_millis"`
+	EndTimeInMillis   int64  `json:"end_time_in_millis"`
+	DurationInMillis  int64  `json:"duration_in_millis"`
+}
+
+type SnapshotStatusResponse struct {
+	Snapshots []struct {
+		Stats struct {
+			TotalSizeInBytes int64 `json:"total_size_in_bytes"`
+			Total            struct {
+				SizeInBytes int64 `json:"size_in_bytes"`
+			} `json:"total"`
+		} `json:"stats"`
+	} `json:"snapshots"`
+}
+
+func CheckClusterSnapshot(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	if c.Repository == "" {
+		plugin.ServiceOutput = "UNKNOWN: Repository is required"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var snapshots SnapshotsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_snapshot/%s/_all", c.ElasticsearchURL, c.Repository), &snapshots) {
+		return plugin
+	}
+
+	sort.Slice(snapshots.Snapshots, func(i, j int) bool {
+		return snapshots.Snapshots[i].StartTimeInMillis > snapshots.Snapshots[j].StartTimeInMillis
+	})
+
+	// Snapshots still running are skipped, only the latest finished one is graded.
+	var latest, lastSuccess *SnapshotInfo
+	for i := range snapshots.Snapshots {
+		snapshot := &snapshots.Snapshots[i]
+		if snapshot.State == "IN_PROGRESS" {
+			continue
+		}
+		if latest == nil {
+			latest = snapshot
+		}
+		if snapshot.State == "SUCCESS" {
+			lastSuccess = snapshot
+			break
+		}
+	}
+
+	if lastSuccess == nil {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: No successful snapshot in repository %s", c.Repository)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	age := time.Since(time.UnixMilli(lastSuccess.EndTimeInMillis)).Hours()
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "age_hours",
+			Value: fmt.Sprintf("%.1f", age),
+			Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+			Min:   "0",
+		},
+		{
+			Label:             "duration",
+			Value:             fmt.Sprintf("%d", lastSuccess.DurationInMillis/1000),
+			Min:               "0",
+			UnitOfMeasurement: "s",
+		},
+	}
+
+	// The size is optional, a failed status request doesn't fail the check.
+	var status SnapshotStatusResponse
+	if fetchJSON(fmt.Sprintf("%s/_snapshot/%s/%s/_status", c.ElasticsearchURL, c.Repository, lastSuccess.Snapshot), &status) == nil && len(status.Snapshots) > 0 {
+		size := status.Snapshots[0].Stats.Total.SizeInBytes
+		if size == 0 {
+			// Elasticsearch before 7.8 only reports the flat total.
+			size = status.Snapshots[0].Stats.TotalSizeInBytes
+		}
+		pd = append(pd, nagios.PerformanceData{
+			Label:             "size",
+			Value:             fmt.Sprintf("%d", size),
+			Min:               "0",
+			UnitOfMeasurement: "B",
+		})
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.LongServiceOutput = fmt.Sprintf("Latest snapshot %s is %s", latest.Snapshot, latest.State)
+
+	if latest.State != "SUCCESS" {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: Latest snapshot %s is %s, last success %s is %.1fh old", latest.Snapshot, latest.State, lastSuccess.Snapshot, age)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, age)
+	plugin.ServiceOutput = fmt.Sprintf("%s: Last successful snapshot %s is %.1fh old",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), lastSuccess.Snapshot, age)
+
+	return plugin
+}
@@ -0,0 +1,131 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+func CheckClusterTasks(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	query := url.Values{"detailed": {"true"}}
+	if c.Actions != "" {
+		query.Set("actions", c.Actions)
+	}
+
+	var tasks TasksResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_tasks?%s", c.ElasticsearchURL, query.Encode()), &tasks) {
+		return plugin
+	}
+
+	type runningTask struct {
+		ID       string
+		NodeName string
+		Task     Task
+		Running  time.Duration
+	}
+
+	all := make(map[string]bool)
+	for _, node := range tasks.Nodes {
+		for id := range node.Tasks {
+			all[id] = true
+		}
+	}
+
+	// Child tasks are left out if their parent is listed, so a reindex or a
+	// search is reported once and not for every shard. Persistent tasks such
+	// as ML jobs, transforms or CCR followers run forever by design and are
+	// only checked if they match the actions option.
+	var running []runningTask
+	for _, node := range tasks.Nodes {
+		for id, task := range node.Tasks {
+			if task.ParentTaskID != "" && all[task.ParentTaskID] {
+				continue
+			}
+			if c.Actions == "" && strings.HasSuffix(task.Action, "[c]") {
+				continue
+			}
+			running = append(running, runningTask{
+				ID:       id,
+				NodeName: node.Name,
+				Task:     task,
+				Running:  time.Duration(task.RunningTimeInNanos),
+			})
+		}
+	}
+
+	sort.Slice(running, func(i, j int) bool { return running[i].Running > running[j].Running })
+
+	var details, longRunning []string
+	for _, task := range running {
+		minutes := task.Running.Minutes()
+		detail := fmt.Sprintf("%s on %s: %s running %s, cancellable: %t",
+			task.ID, task.NodeName, task.Task.Action, task.Running.Round(time.Second), task.Task.Cancellable)
+		if task.Task.Cancelled {
+			detail += " (cancelled)"
+		}
+		if task.Task.Description != "" {
+			description := task.Task.Description
+			if len(description) > 300 {
+				description = description[:300] + "..."
+			}
+			detail += fmt.Sprintf(", %s", description)
+		}
+		details = append(details, detail)
+
+		if gradeAbove(c, minutes) != nagios.StateOKExitCode {
+			longRunning = append(longRunning, task.ID)
+		}
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	var longest float64
+	if len(running) > 0 {
+		longest = running[0].Running.Minutes()
+	}
+
+	pd := []nagios.PerformanceData{
+		{Label: "tasks", Value: fmt.Sprintf("%d", len(running)), Min: "0"},
+		{Label: "long_running_tasks", Value: fmt.Sprintf("%d", len(longRunning)), Min: "0"},
+		{
+			Label: "longest_task_minutes",
+			Value: fmt.Sprintf("%.1f", longest),
+			Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+			Min:   "0",
+		},
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, longest)
+	if len(running) == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("%s: No running tasks", nagios.ExitCodeToStateLabel(plugin.ExitStatusCode))
+		return plugin
+	}
+
+	longestTask := running[0]
+	plugin.ServiceOutput = fmt.Sprintf("%s: %d of %d tasks running too long, longest %s on %s running %s, cancellable: %t",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), len(longRunning), len(running), longestTask.Task.Action,
+		longestTask.NodeName, longestTask.Running.Round(time.Second), longestTask.Task.Cancellable)
+	if longestTask.Task.Description != "" && plugin.ExitStatusCode != nagios.StateOKExitCode {
+		description := longestTask.Task.Description
+		if len(description) > 100 {
+			description = description[:100] + "..."
+		}
+		plugin.ServiceOutput += fmt.Sprintf(", %s", description)
+	}
+
+	return plugin
+}
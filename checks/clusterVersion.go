@@ -0,0 +1,119 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// sortedVersions returns the versions of a count map in ascending order.
+func sortedVersions(counts map[string]int) []string {
+	versions := make([]string, 0, len(counts))
+	for version := range counts {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return helper.CompareVersions(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+func CheckClusterVersion(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	var root RootResponse
+	if !getJSON(plugin, c.ElasticsearchURL, &root) {
+		return plugin
+	}
+
+	var nodesInfo ClusterNodesInfoResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/jvm", c.ElasticsearchURL), &nodesInfo) {
+		return plugin
+	}
+
+	// OpenSearch reports its distribution, Elasticsearch doesn't.
+	distribution := root.Version.Distribution
+	if distribution == "" {
+		distribution = "elasticsearch"
+	}
+
+	nodeIDs := make([]string, 0, len(nodesInfo.Nodes))
+	for id := range nodesInfo.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	versions := make(map[string]int)
+	jvmVersions := make(map[string]int)
+	flavors := make(map[string]bool)
+	var outdated, details []string
+	for _, id := range nodeIDs {
+		node := nodesInfo.Nodes[id]
+		versions[node.Version]++
+		jvmVersions[node.JVM.Version]++
+		if node.BuildFlavor != "" {
+			flavors[node.BuildFlavor] = true
+		}
+
+		detail := fmt.Sprintf("%s: %s, JVM %s %s", node.Name, node.Version, node.JVM.VMVendor, node.JVM.Version)
+		if node.BuildFlavor != "" {
+			detail += fmt.Sprintf(", %s build", node.BuildFlavor)
+		}
+		if c.MinVersion != "" && helper.CompareVersions(node.Version, c.MinVersion) < 0 {
+			outdated = append(outdated, node.Name)
+			detail += fmt.Sprintf(", below %s", c.MinVersion)
+		}
+		details = append(details, detail)
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	pd := []nagios.PerformanceData{
+		{Label: "versions", Value: fmt.Sprintf("%d", len(versions)), Min: "0"},
+		{Label: "jvm_versions", Value: fmt.Sprintf("%d", len(jvmVersions)), Min: "0"},
+		{Label: "outdated_nodes", Value: fmt.Sprintf("%d", len(outdated)), Min: "0"},
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	var versionCounts, jvmCounts []string
+	for _, version := range sortedVersions(versions) {
+		versionCounts = append(versionCounts, fmt.Sprintf("%s (%d nodes)", version, versions[version]))
+	}
+	for _, version := range sortedVersions(jvmVersions) {
+		jvmCounts = append(jvmCounts, fmt.Sprintf("%s (%d nodes)", version, jvmVersions[version]))
+	}
+
+	flavorNames := make([]string, 0, len(flavors))
+	for flavor := range flavors {
+		flavorNames = append(flavorNames, flavor)
+	}
+	sort.Strings(flavorNames)
+
+	build := distribution
+	if len(flavorNames) > 0 {
+		build += fmt.Sprintf(" %s", strings.Join(flavorNames, "/"))
+	}
+	summary := fmt.Sprintf("%s %s, JVM %s", build, strings.Join(versionCounts, ", "), strings.Join(jvmCounts, ", "))
+
+	switch {
+	case len(outdated) > 0:
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: %d nodes below version %s: %s, %s",
+			len(outdated), c.MinVersion, strings.Join(outdated, ", "), summary)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+	case len(versions) > 1:
+		plugin.ServiceOutput = fmt.Sprintf("WARNING: Mixed versions, %s", summary)
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("OK: %s", summary)
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+	}
+
+	return plugin
+}
@@ -0,0 +1,74 @@
+package checks
+
+import (
+	"fmt"
+	"nagios-es/config"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// gradeAbove returns the exit code for a value where higher values are worse.
+func gradeAbove(c *config.Config, value float64) int {
+	switch {
+	case value > float64(c.CriticalThreshold):
+		return nagios.StateCRITICALExitCode
+	case value > float64(c.WarningThreshold):
+		return nagios.StateWARNINGExitCode
+	default:
+		return nagios.StateOKExitCode
+	}
+}
+
+// nodeSelected reports whether a node passes the node_ip and node_name
+// filters. Without filters every node is selected.
+func nodeSelected(c *config.Config, name, ip string) bool {
+	if c.NodeIP == "" && c.NodeName == "" {
+		return true
+	}
+
+	return (c.NodeIP != "" && ip == c.NodeIP) || (c.NodeName != "" && name == c.NodeName)
+}
+
+// gradeBelow returns the exit code for a value where lower values are worse.
+func gradeBelow(c *config.Config, value float64) int {
+	switch {
+	case value < float64(c.CriticalThreshold):
+		return nagios.StateCRITICALExitCode
+	case value < float64(c.WarningThreshold):
+		return nagios.StateWARNINGExitCode
+	default:
+		return nagios.StateOKExitCode
+	}
+}
+
+// grade returns the exit code for a value, lower values are worse if the
+// below option is set and higher values otherwise.
+func grade(c *config.Config, value float64) int {
+	if c.Below {
+		return gradeBelow(c, value)
+	}
+	return gradeAbove(c, value)
+}
+
+// worse reports whether value a is graded worse than value b.
+func worse(c *config.Config, a, b float64) bool {
+	if c.Below {
+		return a < b
+	}
+	return a > b
+}
+
+// perfThresholds returns the warning and critical thresholds in the
+// performance data range format matching grade.
+func perfThresholds(c *config.Config) (string, string) {
+	if c.Below {
+		return fmt.Sprintf("%d:", c.WarningThreshold), fmt.Sprintf("%d:", c.CriticalThreshold)
+	}
+	return fmt.Sprintf("%d", c.WarningThreshold), fmt.Sprintf("%d", c.CriticalThreshold)
+}
+
+// perfLabel removes the characters that aren't allowed in performance data labels.
+func perfLabel(label string) string {
+	return strings.NewReplacer("=", "_", "'", "_").Replace(label)
+}
@@ -0,0 +1,167 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// metricAggregation represents the result of a single-metric or a
+// percentiles aggregation.
+type metricAggregation struct {
+	Value  *float64            `json:"value"`
+	Values map[string]*float64 `json:"values"`
+}
+
+// result returns the aggregated value, using the only percentile for a
+// percentiles aggregation.
+func (m metricAggregation) result() (float64, bool) {
+	if m.Value != nil {
+		return *m.Value, true
+	}
+	for _, value := range m.Values {
+		if value != nil {
+			return *value, true
+		}
+	}
+	return 0, false
+}
+
+type aggregationResponse struct {
+	Aggregations struct {
+		Metric metricAggregation `json:"metric"`
+		Split  struct {
+			Buckets []struct {
+				Key    interface{}       `json:"key"`
+				Metric metricAggregation `json:"metric"`
+			} `json:"buckets"`
+		} `json:"split"`
+	} `json:"aggregations"`
+}
+
+func CheckIndexAggregation(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	if c.Index == "" || c.AggField == "" {
+		plugin.ServiceOutput = "UNKNOWN: Index and aggregation field are required"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	description := c.AggType
+	metric := map[string]interface{}{"field": c.AggField}
+	switch c.AggType {
+	case "avg", "max", "min", "sum", "cardinality":
+	case "percentiles":
+		percentile, err := strconv.ParseFloat(c.Percentile, 64)
+		if err != nil {
+			plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Invalid percentile %s", c.Percentile)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			return plugin
+		}
+		metric["percents"] = []float64{percentile}
+		description = fmt.Sprintf("p%s", c.Percentile)
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported aggregation %s", c.AggType)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	query, err := buildQuery(c)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	aggs := map[string]interface{}{
+		"metric": map[string]interface{}{c.AggType: metric},
+	}
+	if c.SplitField != "" {
+		aggs = map[string]interface{}{
+			"split": map[string]interface{}{
+				"terms": map[string]interface{}{"field": c.SplitField, "size": c.SplitSize},
+				"aggs":  aggs,
+			},
+		}
+	}
+
+	body := map[string]interface{}{
+		"query": query,
+		"size":  0,
+		"aggs":  aggs,
+	}
+
+	var result aggregationResponse
+	if !postJSON(plugin, fmt.Sprintf("%s/%s/_search", c.ElasticsearchURL, c.Index), body, &result) {
+		return plugin
+	}
+
+	// Without a split field the whole index is graded as a single bucket.
+	type bucket struct {
+		Name  string
+		Value float64
+	}
+	var buckets []bucket
+	if c.SplitField != "" {
+		for _, b := range result.Aggregations.Split.Buckets {
+			if value, ok := b.Metric.result(); ok {
+				buckets = append(buckets, bucket{Name: fmt.Sprintf("%v", b.Key), Value: value})
+			}
+		}
+	} else if value, ok := result.Aggregations.Metric.result(); ok {
+		buckets = append(buckets, bucket{Name: c.Index, Value: value})
+	}
+
+	if len(buckets) == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: No documents with %s found in %s", c.AggField, c.Index)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var failing int
+	worst := buckets[0]
+	for _, b := range buckets {
+		if worse(c, b.Value, worst.Value) {
+			worst = b
+		}
+
+		state := grade(c, b.Value)
+		if state != nagios.StateOKExitCode {
+			failing++
+		}
+		details = append(details, fmt.Sprintf("%s: %s is %.2f", nagios.ExitCodeToStateLabel(state), b.Name, b.Value))
+
+		bucketPerfData := nagios.PerformanceData{
+			Label: perfLabel(b.Name),
+			Value: fmt.Sprintf("%.2f", b.Value),
+		}
+		bucketPerfData.Warn, bucketPerfData.Crit = perfThresholds(c)
+		pd = append(pd, bucketPerfData)
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = grade(c, worst.Value)
+	if c.SplitField != "" {
+		plugin.ServiceOutput = fmt.Sprintf("%s: %s of %s for %s %s is %.2f, %d of %d buckets not OK",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, c.AggField, c.SplitField, worst.Name, worst.Value, failing, len(buckets))
+	} else {
+		plugin.ServiceOutput = fmt.Sprintf("%s: %s of %s is %.2f",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, c.AggField, worst.Value)
+	}
+
+	return plugin
+}
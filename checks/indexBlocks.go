@@ -0,0 +1,98 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// indexBlocks lists the index blocks that stop writes or metadata changes.
+var indexBlocks = []string{"read_only", "read_only_allow_delete", "write", "metadata"}
+
+// indexAllowed reports whether the index matches one of the allow-list patterns.
+func indexAllowed(index string, allow []string) bool {
+	for _, pattern := range allow {
+		if matched, _ := path.Match(pattern, index); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func CheckIndexBlocks(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	pattern := c.Index
+	if pattern == "" {
+		pattern = "_all"
+	}
+
+	var settings IndexSettingsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/%s/_settings/index.blocks.*?flat_settings=true", c.ElasticsearchURL, pattern), &settings) {
+		return plugin
+	}
+
+	allow := helper.SplitList(c.Allow)
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counts := make(map[string]int)
+	var blocked, allowed, details []string
+	for _, name := range names {
+		var active []string
+		for _, block := range indexBlocks {
+			if value := fmt.Sprintf("%v", settings[name].Settings["index.blocks."+block]); value == "true" {
+				active = append(active, block)
+			}
+		}
+
+		if len(active) == 0 {
+			continue
+		}
+
+		if indexAllowed(name, allow) {
+			allowed = append(allowed, name)
+			details = append(details, fmt.Sprintf("%s: %s (allowed)", name, strings.Join(active, ", ")))
+			continue
+		}
+
+		for _, block := range active {
+			counts[block]++
+		}
+		blocked = append(blocked, name)
+		details = append(details, fmt.Sprintf("%s: %s", name, strings.Join(active, ", ")))
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	var pd []nagios.PerformanceData
+	for _, block := range indexBlocks {
+		pd = append(pd, nagios.PerformanceData{Label: block, Value: fmt.Sprintf("%d", counts[block]), Min: "0"})
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	if len(blocked) > 0 {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: %d blocked indices: %s", len(blocked), strings.Join(blocked, ", "))
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("OK: No blocked indices, %d allowed", len(allowed))
+	plugin.ExitStatusCode = nagios.StateOKExitCode
+
+	return plugin
+}
@@ -0,0 +1,134 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// maxAggregation represents the result of a max aggregation, Value is nil if
+// no document has the field.
+type maxAggregation struct {
+	Value *float64 `json:"value"`
+}
+
+type freshnessResponse struct {
+	Aggregations struct {
+		Newest maxAggregation `json:"newest"`
+		Split  struct {
+			Buckets []struct {
+				Key    interface{}    `json:"key"`
+				Newest maxAggregation `json:"newest"`
+			} `json:"buckets"`
+		} `json:"split"`
+	} `json:"aggregations"`
+}
+
+func CheckIndexFreshness(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	if c.Index == "" {
+		plugin.ServiceOutput = "UNKNOWN: Index is required"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	newest := map[string]interface{}{
+		"max": map[string]interface{}{"field": c.TimestampField},
+	}
+
+	aggs := map[string]interface{}{"newest": newest}
+	if c.SplitField != "" {
+		// The stalest sources come first, so they are kept when there are
+		// more sources than split_size.
+		aggs = map[string]interface{}{
+			"split": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": c.SplitField,
+					"size":  c.SplitSize,
+					"order": map[string]interface{}{"newest": "asc"},
+				},
+				"aggs": map[string]interface{}{"newest": newest},
+			},
+		}
+	}
+
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": aggs,
+	}
+
+	var result freshnessResponse
+	if !postJSON(plugin, fmt.Sprintf("%s/%s/_search", c.ElasticsearchURL, c.Index), query, &result) {
+		return plugin
+	}
+
+	// Without a split field the whole index is graded as a single source.
+	type source struct {
+		Name   string
+		Newest float64
+	}
+	var sources []source
+	if c.SplitField != "" {
+		for _, bucket := range result.Aggregations.Split.Buckets {
+			if bucket.Newest.Value == nil {
+				continue
+			}
+			sources = append(sources, source{Name: fmt.Sprintf("%v", bucket.Key), Newest: *bucket.Newest.Value})
+		}
+	} else if result.Aggregations.Newest.Value != nil {
+		sources = append(sources, source{Name: c.Index, Newest: *result.Aggregations.Newest.Value})
+	}
+
+	if len(sources) == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("CRITICAL: No documents with %s found in %s", c.TimestampField, c.Index)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+		return plugin
+	}
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var maxAge float64
+	var oldest string
+	for i, s := range sources {
+		newestTime := time.UnixMilli(int64(s.Newest))
+		age := time.Since(newestTime).Minutes()
+		if i == 0 || age > maxAge {
+			maxAge = age
+			oldest = s.Name
+		}
+
+		details = append(details, fmt.Sprintf("%s: newest document at %s (%.0fm old)", s.Name, newestTime.UTC().Format(time.RFC3339), age))
+
+		agePerfData := nagios.PerformanceData{
+			Label: perfLabel(fmt.Sprintf("%s_age_minutes", s.Name)),
+			Value: fmt.Sprintf("%.1f", age),
+			Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+		}
+		pd = append(pd, agePerfData)
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, maxAge)
+	if c.SplitField != "" {
+		plugin.ServiceOutput = fmt.Sprintf("%s: Newest document for %s %s is %.0fm old",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), c.SplitField, oldest, maxAge)
+	} else {
+		plugin.ServiceOutput = fmt.Sprintf("%s: Newest document in %s is %.0fm old",
+			nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), c.Index, maxAge)
+	}
+
+	return plugin
+}
@@ -0,0 +1,170 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// indexCategories lists the categories of indices that can be limited.
+var indexCategories = []string{"red", "yellow", "closed"}
+
+// categoryThresholds holds the warning and critical counts of a category, a
+// negative value disables that level.
+type categoryThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// defaultIndexThresholds alerts on any red, yellow or closed index, the
+// index_thresholds option overrides single categories.
+var defaultIndexThresholds = map[string]categoryThresholds{
+	"red":    {Warning: -1, Critical: 0},
+	"yellow": {Warning: 0, Critical: -1},
+	"closed": {Warning: 0, Critical: -1},
+}
+
+// parseCategoryThresholds parses thresholds in the form category=warn:crit
+// into thresholds, the count must exceed a threshold to alert.
+func parseCategoryThresholds(spec string, thresholds map[string]categoryThresholds) error {
+	for _, item := range helper.SplitList(spec) {
+		category, bounds, ok := strings.Cut(item, "=")
+		if !ok || !isIndexCategory(category) {
+			return fmt.Errorf("invalid index threshold %q", item)
+		}
+
+		warning, critical, _ := strings.Cut(bounds, ":")
+		t := categoryThresholds{Warning: -1, Critical: -1}
+		for _, level := range []struct {
+			value  string
+			target *int
+		}{{warning, &t.Warning}, {critical, &t.Critical}} {
+			if level.value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(level.value)
+			if err != nil {
+				return fmt.Errorf("invalid index threshold %q", item)
+			}
+			*level.target = n
+		}
+
+		thresholds[category] = t
+	}
+	return nil
+}
+
+func isIndexCategory(category string) bool {
+	for _, c := range indexCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func CheckIndexInventory(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	thresholds := make(map[string]categoryThresholds)
+	for category, t := range defaultIndexThresholds {
+		thresholds[category] = t
+	}
+
+	if err := parseCategoryThresholds(c.IndexThresholds, thresholds); err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	url := fmt.Sprintf("%s/_cat/indices?format=json&expand_wildcards=all", c.ElasticsearchURL)
+	if c.Index != "" {
+		url = fmt.Sprintf("%s/_cat/indices/%s?format=json&expand_wildcards=all", c.ElasticsearchURL, c.Index)
+	}
+
+	var indices []CatIndex
+	if !getJSON(plugin, url, &indices) {
+		return plugin
+	}
+
+	sort.Slice(indices, func(i, j int) bool { return indices[i].Index < indices[j].Index })
+
+	allow := helper.SplitList(c.Allow)
+	members := make(map[string][]string)
+	counts := make(map[string]int)
+	for _, index := range indices {
+		category := index.Health
+		if index.Status == "close" {
+			category = "closed"
+		}
+		counts[category]++
+
+		if isIndexCategory(category) && !indexAllowed(index.Index, allow) {
+			members[category] = append(members[category], index.Index)
+		}
+	}
+
+	var pd []nagios.PerformanceData
+	var details, problems []string
+	exitCode := nagios.StateOKExitCode
+	for _, category := range indexCategories {
+		count := len(members[category])
+		t := thresholds[category]
+
+		state := nagios.StateOKExitCode
+		switch {
+		case t.Critical >= 0 && count > t.Critical:
+			state = nagios.StateCRITICALExitCode
+		case t.Warning >= 0 && count > t.Warning:
+			state = nagios.StateWARNINGExitCode
+		}
+		if state > exitCode {
+			exitCode = state
+		}
+		if state != nagios.StateOKExitCode {
+			problems = append(problems, fmt.Sprintf("%d %s", count, category))
+		}
+
+		if count > 0 {
+			details = append(details, fmt.Sprintf("%s: %s", category, strings.Join(members[category], ", ")))
+		}
+
+		categoryPerfData := nagios.PerformanceData{
+			Label: fmt.Sprintf("%s_indices", category),
+			Value: fmt.Sprintf("%d", count),
+			Min:   "0",
+		}
+		if t.Warning >= 0 {
+			categoryPerfData.Warn = fmt.Sprintf("%d", t.Warning)
+		}
+		if t.Critical >= 0 {
+			categoryPerfData.Crit = fmt.Sprintf("%d", t.Critical)
+		}
+		pd = append(pd, categoryPerfData)
+	}
+	pd = append(pd, nagios.PerformanceData{Label: "green_indices", Value: fmt.Sprintf("%d", counts["green"]), Min: "0"})
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = exitCode
+	if len(problems) > 0 {
+		plugin.ServiceOutput = fmt.Sprintf("%s: %s indices", nagios.ExitCodeToStateLabel(exitCode), strings.Join(problems, ", "))
+		return plugin
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("OK: %d indices, %d green, %d yellow, %d red, %d closed",
+		len(indices), counts["green"], counts["yellow"], counts["red"], counts["closed"])
+
+	return plugin
+}
@@ -0,0 +1,189 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/state"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+const totalFieldsLimitSetting = "index.mapping.total_fields.limit"
+
+// defaultTotalFieldsLimit is used if an index has no total fields limit.
+const defaultTotalFieldsLimit = 1000
+
+type IndexMappingResponse map[string]struct {
+	Mappings map[string]interface{} `json:"mappings"`
+}
+
+// countFields counts the fields of a mapping the way the total fields limit
+// does: object fields, multi-fields, runtime fields and aliases all count.
+func countFields(mapping map[string]interface{}) int {
+	var count int
+	for _, key := range []string{"properties", "fields", "runtime"} {
+		fields, ok := mapping[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range fields {
+			count++
+			if field, ok := field.(map[string]interface{}); ok && key != "runtime" {
+				count += countFields(field)
+			}
+		}
+	}
+	return count
+}
+
+func CheckIndexMapping(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	pattern := c.Index
+	if pattern == "" {
+		pattern = "_all"
+	}
+
+	// The mapping and settings APIs don't return the cluster name.
+	var root RootResponse
+	if !getJSON(plugin, c.ElasticsearchURL, &root) {
+		return plugin
+	}
+
+	var mappings IndexMappingResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/%s/_mapping", c.ElasticsearchURL, pattern), &mappings) {
+		return plugin
+	}
+
+	var settings IndexSettingsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/%s/_settings/%s?include_defaults=true&flat_settings=true", c.ElasticsearchURL, pattern, totalFieldsLimitSetting), &settings) {
+		return plugin
+	}
+
+	store, ok := openState(plugin, c)
+	if !ok {
+		return plugin
+	}
+	defer closeState(plugin, store)
+
+	names := make([]string, 0, len(mappings))
+	for name := range mappings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type indexFields struct {
+		Name     string
+		Fields   int
+		Limit    int
+		Headroom float64
+		Growth   float64
+	}
+	var indices, growing []indexFields
+	var details []string
+	for _, name := range names {
+		mapping := mappings[name].Mappings
+
+		// Before Elasticsearch 7 the fields are nested under the mapping type.
+		if _, ok := mapping["properties"]; !ok && len(mapping) == 1 {
+			for _, typeMapping := range mapping {
+				if typeMapping, ok := typeMapping.(map[string]interface{}); ok {
+					mapping = typeMapping
+				}
+			}
+		}
+
+		limit := defaultTotalFieldsLimit
+		value, ok := settings[name].Settings[totalFieldsLimitSetting]
+		if !ok {
+			value, ok = settings[name].Defaults[totalFieldsLimitSetting]
+		}
+		if ok {
+			if n, err := strconv.Atoi(fmt.Sprintf("%v", value)); err == nil && n > 0 {
+				limit = n
+			}
+		}
+
+		index := indexFields{Name: name, Fields: countFields(mapping), Limit: limit}
+		index.Headroom = 100 * float64(limit-index.Fields) / float64(limit)
+
+		detail := fmt.Sprintf("%s: %d of %d fields, %.1f%% headroom", name, index.Fields, limit, index.Headroom)
+		// Only the current and the first count within forecast_window are
+		// kept, so the state file stays small with thousands of indices.
+		if store != nil {
+			now := float64(time.Now().Unix())
+			sample := map[string]float64{"fields": float64(index.Fields), "first_fields": float64(index.Fields), "first_time": now}
+
+			key := state.Key(root.ClusterName, "mapping", "", name)
+			if previous, ok := store.Get(key); ok {
+				sample["first_fields"] = previous.Values["first_fields"]
+				sample["first_time"] = previous.Values["first_time"]
+				if now-sample["first_time"] > c.ForecastWindow.Seconds() {
+					sample["first_fields"] = previous.Values["fields"]
+					sample["first_time"] = float64(previous.Time.Unix())
+				}
+			}
+			store.Put(key, sample)
+
+			if hours := (now - sample["first_time"]) / 3600; hours > 0 && sample["fields"] > sample["first_fields"] {
+				index.Growth = (sample["fields"] - sample["first_fields"]) / hours
+				growing = append(growing, index)
+				detail += fmt.Sprintf(", growing %.1f fields/h", index.Growth)
+			}
+		}
+
+		indices = append(indices, index)
+		details = append(details, detail)
+	}
+
+	if len(indices) == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: No indices match %s", pattern)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	sort.SliceStable(indices, func(i, j int) bool { return indices[i].Headroom < indices[j].Headroom })
+	sort.SliceStable(growing, func(i, j int) bool { return growing[i].Growth > growing[j].Growth })
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	tightest := indices[0]
+	headroomPerfData := nagios.PerformanceData{
+		Label:             "min_headroom",
+		Value:             fmt.Sprintf("%.1f", tightest.Headroom),
+		Warn:              fmt.Sprintf("%d:", c.WarningThreshold),
+		Crit:              fmt.Sprintf("%d:", c.CriticalThreshold),
+		Max:               "100",
+		UnitOfMeasurement: "%",
+	}
+	pd := []nagios.PerformanceData{
+		headroomPerfData,
+		{Label: "max_fields", Value: fmt.Sprintf("%d", tightest.Fields), Min: "0", Max: fmt.Sprintf("%d", tightest.Limit)},
+		{Label: "indices", Value: fmt.Sprintf("%d", len(indices)), Min: "0"},
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeBelow(c, tightest.Headroom)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s has %.1f%% field headroom (%d of %d fields)",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), tightest.Name, tightest.Headroom, tightest.Fields, tightest.Limit)
+
+	if len(growing) > 0 {
+		var fastest []string
+		for i := 0; i < len(growing) && i < 3; i++ {
+			fastest = append(fastest, fmt.Sprintf("%s (%.1f fields/h)", growing[i].Name, growing[i].Growth))
+		}
+		plugin.ServiceOutput += fmt.Sprintf(", fastest growing: %s", strings.Join(fastest, ", "))
+	}
+
+	return plugin
+}
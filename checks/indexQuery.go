@@ -0,0 +1,162 @@
+package checks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"os"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// hitsTotal is the total number of hits, which is an object since
+// Elasticsearch 7 and a plain number before.
+type hitsTotal struct {
+	Value    int64  `json:"value"`
+	Relation string `json:"relation"`
+}
+
+func (t *hitsTotal) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '{' {
+		return json.Unmarshal(data, &t.Value)
+	}
+
+	type plain hitsTotal
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total hitsTotal `json:"total"`
+		Hits  []struct {
+			Index  string          `json:"_index"`
+			ID     string          `json:"_id"`
+			Source json.RawMessage `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// buildQuery combines the query, query_file or query_string option with the
+// time_range filter on the timestamp field.
+func buildQuery(c *config.Config) (map[string]interface{}, error) {
+	var filters []interface{}
+
+	switch {
+	case c.Query != "" || c.QueryFile != "":
+		raw := []byte(c.Query)
+		if c.QueryFile != "" {
+			var err error
+			if raw, err = os.ReadFile(c.QueryFile); err != nil {
+				return nil, err
+			}
+		}
+
+		var query map[string]interface{}
+		if err := json.Unmarshal(raw, &query); err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+
+		// A full search body is accepted as well as a bare query.
+		if inner, ok := query["query"].(map[string]interface{}); ok {
+			query = inner
+		}
+		filters = append(filters, query)
+	case c.QueryString != "":
+		filters = append(filters, map[string]interface{}{
+			"query_string": map[string]interface{}{"query": c.QueryString},
+		})
+	}
+
+	if c.TimeRange > 0 {
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{
+				c.TimestampField: map[string]interface{}{
+					"gte": fmt.Sprintf("now-%ds", int64(c.TimeRange.Seconds())),
+				},
+			},
+		})
+	}
+
+	if len(filters) == 0 {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}, nil
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"filter": filters},
+	}, nil
+}
+
+func CheckIndexQuery(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	if c.Index == "" {
+		plugin.ServiceOutput = "UNKNOWN: Index is required"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	query, err := buildQuery(c)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	body := map[string]interface{}{
+		"query":            query,
+		"size":             c.SampleSize,
+		"track_total_hits": true,
+		"sort": []interface{}{
+			map[string]interface{}{c.TimestampField: map[string]interface{}{"order": "desc", "unmapped_type": "date"}},
+		},
+	}
+
+	var result searchResponse
+	if !postJSON(plugin, fmt.Sprintf("%s/%s/_search", c.ElasticsearchURL, c.Index), body, &result) {
+		return plugin
+	}
+
+	count := result.Hits.Total.Value
+
+	var details []string
+	for _, hit := range result.Hits.Hits {
+		var source bytes.Buffer
+		if err := json.Compact(&source, hit.Source); err != nil {
+			source.Write(hit.Source)
+		}
+
+		doc := source.String()
+		if len(doc) > 300 {
+			doc = doc[:300] + "..."
+		}
+		details = append(details, fmt.Sprintf("%s/%s: %s", hit.Index, hit.ID, doc))
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	countPerfData := nagios.PerformanceData{
+		Label: "count",
+		Value: fmt.Sprintf("%d", count),
+		Min:   "0",
+	}
+	countPerfData.Warn, countPerfData.Crit = perfThresholds(c)
+
+	if err := plugin.AddPerfData(false, countPerfData); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	window := ""
+	if c.TimeRange > 0 {
+		window = fmt.Sprintf(" in the last %s", c.TimeRange)
+	}
+
+	plugin.ExitStatusCode = grade(c, float64(count))
+	plugin.ServiceOutput = fmt.Sprintf("%s: %d matching documents in %s%s",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), count, c.Index, window)
+
+	return plugin
+}
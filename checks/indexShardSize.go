@@ -0,0 +1,164 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// shardSizeLimits holds the size limits in bytes, zero disables a limit.
+type shardSizeLimits struct {
+	Max int64
+	Min int64
+}
+
+func parseShardSizeLimits(maxSize, minSize string) (shardSizeLimits, error) {
+	var limits shardSizeLimits
+	var err error
+
+	if maxSize != "" {
+		if limits.Max, err = helper.ParseByteSize(maxSize); err != nil {
+			return limits, err
+		}
+	}
+
+	if minSize != "" {
+		if limits.Min, err = helper.ParseByteSize(minSize); err != nil {
+			return limits, err
+		}
+	}
+
+	return limits, nil
+}
+
+// shardSizeLimitsFor returns the limits of the first config file rule
+// matching the index, or the defaults from the options.
+func shardSizeLimitsFor(index string, rules []config.ShardSizeRule, ruleLimits []shardSizeLimits, defaults shardSizeLimits) shardSizeLimits {
+	for i, rule := range rules {
+		if matched, _ := path.Match(rule.Pattern, index); matched {
+			return ruleLimits[i]
+		}
+	}
+	return defaults
+}
+
+func CheckIndexShardSize(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	defaults, err := parseShardSizeLimits(c.MaxShardSize, c.MinShardSize)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	ruleLimits := make([]shardSizeLimits, len(c.ShardSizeRules))
+	for i, rule := range c.ShardSizeRules {
+		if ruleLimits[i], err = parseShardSizeLimits(rule.Max, rule.Min); err != nil {
+			plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: shard_size rule %s: %v", rule.Pattern, err)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			return plugin
+		}
+	}
+
+	url := fmt.Sprintf("%s/_cat/shards?format=json&bytes=b", c.ElasticsearchURL)
+	if c.Index != "" {
+		url = fmt.Sprintf("%s/_cat/shards/%s?format=json&bytes=b", c.ElasticsearchURL, c.Index)
+	}
+
+	var shards []CatShard
+	if !getJSON(plugin, url, &shards) {
+		return plugin
+	}
+
+	type shardSize struct {
+		Name  string
+		Size  int64
+		Limit int64
+	}
+	var oversized, undersized []shardSize
+	var checked int
+	for _, shard := range shards {
+		if shard.Store == "" || (c.PrimariesOnly && shard.Prirep != "p") {
+			continue
+		}
+
+		size, err := strconv.ParseInt(shard.Store, 10, 64)
+		if err != nil {
+			continue
+		}
+		checked++
+
+		name := fmt.Sprintf("%s[%s][%s]", shard.Index, shard.Shard, shard.Prirep)
+		limits := shardSizeLimitsFor(shard.Index, c.ShardSizeRules, ruleLimits, defaults)
+		switch {
+		case limits.Max > 0 && size > limits.Max:
+			oversized = append(oversized, shardSize{Name: name, Size: size, Limit: limits.Max})
+		case limits.Min > 0 && size < limits.Min:
+			undersized = append(undersized, shardSize{Name: name, Size: size, Limit: limits.Min})
+		}
+	}
+
+	sort.Slice(oversized, func(i, j int) bool { return oversized[i].Size > oversized[j].Size })
+	sort.Slice(undersized, func(i, j int) bool { return undersized[i].Size < undersized[j].Size })
+
+	// Only the worst offenders are listed.
+	const listed = 10
+	var details []string
+	for i, shard := range oversized {
+		if i == listed {
+			details = append(details, fmt.Sprintf("... and %d more oversized shards", len(oversized)-listed))
+			break
+		}
+		details = append(details, fmt.Sprintf("oversized %s: %d bytes > %d", shard.Name, shard.Size, shard.Limit))
+	}
+	for i, shard := range undersized {
+		if i == listed {
+			details = append(details, fmt.Sprintf("... and %d more undersized shards", len(undersized)-listed))
+			break
+		}
+		details = append(details, fmt.Sprintf("undersized %s: %d bytes < %d", shard.Name, shard.Size, shard.Limit))
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	offenders := len(oversized) + len(undersized)
+	pd := []nagios.PerformanceData{
+		{
+			Label: "oversized_shards",
+			Value: fmt.Sprintf("%d", len(oversized)),
+			Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+			Min:   "0",
+		},
+		{
+			Label: "undersized_shards",
+			Value: fmt.Sprintf("%d", len(undersized)),
+			Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+			Min:   "0",
+		},
+		{Label: "shards", Value: fmt.Sprintf("%d", checked), Min: "0"},
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, float64(offenders))
+	plugin.ServiceOutput = fmt.Sprintf("%s: %d oversized and %d undersized of %d shards",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), len(oversized), len(undersized), checked)
+	if len(oversized) > 0 {
+		plugin.ServiceOutput += fmt.Sprintf(", largest %s", oversized[0].Name)
+	}
+
+	return plugin
+}
@@ -0,0 +1,203 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/state"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// throughputSource is a node or an index with its operation counters.
+type throughputSource struct {
+	ID     string
+	Name   string
+	Total  int64
+	Millis int64
+}
+
+func CheckIndexing(c *config.Config) *nagios.Plugin {
+	return checkThroughput(c, "indexing", "Indexing rate", "docs/s", func(s IndicesStats) (int64, int64) {
+		return s.Indexing.IndexTotal, s.Indexing.IndexTimeInMillis
+	})
+}
+
+func CheckSearch(c *config.Config) *nagios.Plugin {
+	return checkThroughput(c, "search", "Search rate", "queries/s", func(s IndicesStats) (int64, int64) {
+		return s.Search.QueryTotal, s.Search.QueryTimeInMillis
+	})
+}
+
+// checkThroughput grades the operation rate or the average latency per
+// operation since the last run, per node or per index if the index option is set.
+func checkThroughput(c *config.Config, check, rateDescription, rateUnit string, counters func(IndicesStats) (int64, int64)) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "rate"
+	}
+
+	var description, unit string
+	switch metric {
+	case "rate":
+		description = rateDescription
+		unit = rateUnit
+	case "latency":
+		description = fmt.Sprintf("Average %s latency", check)
+		unit = "ms"
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported %s metric %s", check, metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	if c.StateFile == "" {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: The %s check requires a state file", check)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var cluster, scope string
+	var sources []throughputSource
+	if c.Index != "" {
+		var indicesStats IndicesStatsResponse
+		if !getJSON(plugin, fmt.Sprintf("%s/%s/_stats/indexing,search", c.ElasticsearchURL, c.Index), &indicesStats) {
+			return plugin
+		}
+
+		// The _stats API doesn't return the cluster name.
+		cluster = c.ElasticsearchURL
+		scope = "index"
+
+		indexCounters := func(index IndexStats) (int64, int64) {
+			if check == "indexing" {
+				return counters(index.Primaries)
+			}
+			return counters(index.Total)
+		}
+
+		// Rolled over backing indices of a data stream or alias stay at zero,
+		// so a drop is graded on the sum of all matching indices.
+		if c.Below {
+			scope = "pattern"
+			total, millis := indexCounters(indicesStats.All)
+			sources = append(sources, throughputSource{ID: c.Index, Name: c.Index, Total: total, Millis: millis})
+		} else {
+			for name, index := range indicesStats.Indices {
+				total, millis := indexCounters(index)
+				sources = append(sources, throughputSource{ID: name, Name: name, Total: total, Millis: millis})
+			}
+		}
+	} else {
+		var nodeStats ClusterNodesStatsResponse
+		if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/indices/indexing,search", c.ElasticsearchURL), &nodeStats) {
+			return plugin
+		}
+
+		cluster = nodeStats.ClusterName
+		scope = "node"
+
+		// Master, coordinating and ML nodes hold no shards and always report
+		// zero, so only data nodes are graded.
+		for id, node := range nodeStats.Nodes {
+			if !nodeSelected(c, node.Name, node.IP) || !isDataNode(node.Roles, "") {
+				continue
+			}
+			total, millis := counters(node.Indices)
+			sources = append(sources, throughputSource{ID: id, Name: node.Name, Total: total, Millis: millis})
+		}
+	}
+
+	if len(sources) == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: No matching %ss found", scope)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+
+	store, ok := openState(plugin, c)
+	if !ok {
+		return plugin
+	}
+	defer closeState(plugin, store)
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var worstValue float64
+	var worstName string
+	var rated int
+	for _, source := range sources {
+		key := state.Key(cluster, check, source.ID)
+		previous, _ := store.Get(key)
+		current := store.Put(key, map[string]float64{"total": float64(source.Total), "time": float64(source.Millis)})
+
+		operations, seconds, totalOK := state.Delta(previous, current, "total")
+		millis, _, timeOK := state.Delta(previous, current, "time")
+		if !totalOK || !timeOK {
+			details = append(details, fmt.Sprintf("%s: no previous sample", source.Name))
+			continue
+		}
+
+		rate := operations / seconds
+		var latency float64
+		if operations > 0 {
+			latency = millis / operations
+		}
+
+		value := rate
+		if metric == "latency" {
+			value = latency
+		}
+
+		rated++
+		if rated == 1 || worse(c, value, worstValue) {
+			worstValue = value
+			worstName = source.Name
+		}
+
+		details = append(details, fmt.Sprintf("%s: %.2f %s, %.2fms average latency", source.Name, rate, rateUnit, latency))
+
+		ratePerfData := nagios.PerformanceData{
+			Label: fmt.Sprintf("%s_rate", source.Name),
+			Value: fmt.Sprintf("%.2f", rate),
+			Min:   "0",
+		}
+		latencyPerfData := nagios.PerformanceData{
+			Label:             fmt.Sprintf("%s_latency", source.Name),
+			Value:             fmt.Sprintf("%.2f", latency),
+			Min:               "0",
+			UnitOfMeasurement: "ms",
+		}
+		if metric == "latency" {
+			latencyPerfData.Warn, latencyPerfData.Crit = perfThresholds(c)
+		} else {
+			ratePerfData.Warn, ratePerfData.Crit = perfThresholds(c)
+		}
+		pd = append(pd, ratePerfData, latencyPerfData)
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if rated == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("OK: No previous %s sample yet, rates are available after the next run", check)
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+		return plugin
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = grade(c, worstValue)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s on %s %s is %.2f %s",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, scope, worstName, worstValue, unit)
+
+	return plugin
+}
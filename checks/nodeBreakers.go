@@ -0,0 +1,126 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+func CheckNodeBreakers(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "percent"
+	}
+
+	var description, unit string
+	switch metric {
+	case "percent":
+		description = "Estimated size"
+		unit = "%"
+	case "tripped":
+		description = "Tripped count"
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported breaker metric %s", metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/breaker", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	wanted := helper.SplitList(c.Breakers)
+
+	nodeIDs := make([]string, 0, len(nodeStats.Nodes))
+	for id := range nodeStats.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var maxValue float64
+	var maxNode, maxBreaker string
+	var checked int
+	for _, id := range nodeIDs {
+		node := nodeStats.Nodes[id]
+		if !nodeSelected(c, node.Name, node.IP) {
+			continue
+		}
+
+		names := wanted
+		if len(names) == 0 {
+			for name := range node.Breakers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+
+		for _, name := range names {
+			breaker, ok := node.Breakers[name]
+			if !ok {
+				continue
+			}
+
+			var percent float64
+			if breaker.LimitSizeInBytes > 0 {
+				percent = 100 * float64(breaker.EstimatedSizeInBytes) / float64(breaker.LimitSizeInBytes)
+			}
+
+			value := percent
+			if metric == "tripped" {
+				value = float64(breaker.Tripped)
+			}
+
+			checked++
+			if checked == 1 || value > maxValue {
+				maxValue = value
+				maxNode = node.Name
+				maxBreaker = name
+			}
+
+			details = append(details, fmt.Sprintf("%s %s: %.1f%% of limit, tripped %d", node.Name, name, percent, breaker.Tripped))
+
+			breakerPerfData := nagios.PerformanceData{
+				Label:             fmt.Sprintf("%s_%s_%s", node.Name, name, metric),
+				Value:             fmt.Sprintf("%.2f", value),
+				Warn:              fmt.Sprintf("%d", c.WarningThreshold),
+				Crit:              fmt.Sprintf("%d", c.CriticalThreshold),
+				Min:               "0",
+				UnitOfMeasurement: unit,
+			}
+			if metric == "percent" {
+				breakerPerfData.Max = "100"
+			}
+			pd = append(pd, breakerPerfData)
+		}
+	}
+
+	if checked == 0 {
+		plugin.ServiceOutput = "UNKNOWN: No circuit breakers found"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, maxValue)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s of %s breaker on node %s is %.2f%s",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, maxBreaker, maxNode, maxValue, unit)
+
+	return plugin
+}
@@ -0,0 +1,173 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"nagios-es/state"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+const floodStageSetting = "cluster.routing.allocation.disk.watermark.flood_stage"
+
+// floodStageUsedBytes returns the used bytes at which the flood stage
+// watermark is reached on a disk of the given size. Percentages and ratios
+// are used space, absolute values are the free space that must remain.
+func floodStageUsedBytes(watermark string, total int64) (int64, error) {
+	watermark = strings.TrimSpace(watermark)
+
+	if percent, ok := strings.CutSuffix(watermark, "%"); ok {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid flood stage watermark %q", watermark)
+		}
+		return int64(float64(total) * value / 100), nil
+	}
+
+	if ratio, err := strconv.ParseFloat(watermark, 64); err == nil {
+		return int64(float64(total) * ratio), nil
+	}
+
+	free, err := helper.ParseByteSize(watermark)
+	if err != nil {
+		return 0, fmt.Errorf("invalid flood stage watermark %q", watermark)
+	}
+
+	return total - free, nil
+}
+
+// checkNodeDiskForecast grades the hours until a node disk is full or
+// reaches the flood stage watermark, based on the growth of the used bytes
+// recorded in the state file.
+func checkNodeDiskForecast(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	if c.StateFile == "" {
+		plugin.ServiceOutput = "UNKNOWN: The disk forecast requires a state file"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var targetDescription string
+	switch c.ForecastTarget {
+	case "full":
+		targetDescription = "full"
+	case "flood_stage":
+		targetDescription = "at flood stage"
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported forecast target %s", c.ForecastTarget)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/fs", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	var watermark string
+	if c.ForecastTarget == "flood_stage" {
+		var settings ClusterSettingsResponse
+		if !getJSON(plugin, fmt.Sprintf("%s/_cluster/settings?include_defaults=true&flat_settings=true", c.ElasticsearchURL), &settings) {
+			return plugin
+		}
+
+		var ok bool
+		if watermark, ok = settings.Setting(floodStageSetting); !ok {
+			plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Setting %s not found", floodStageSetting)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			return plugin
+		}
+	}
+
+	store, ok := openState(plugin, c)
+	if !ok {
+		return plugin
+	}
+	defer closeState(plugin, store)
+
+	nodeIDs := make([]string, 0, len(nodeStats.Nodes))
+	for id := range nodeStats.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var minETA float64
+	var minNode string
+	var growing int
+	for _, id := range nodeIDs {
+		node := nodeStats.Nodes[id]
+		if !nodeSelected(c, node.Name, node.IP) {
+			continue
+		}
+
+		total := node.FS.Total.TotalInBytes
+		used := total - node.FS.Total.AvailableInBytes
+
+		limit := total
+		if watermark != "" {
+			var err error
+			if limit, err = floodStageUsedBytes(watermark, total); err != nil {
+				plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: %v", err)
+				plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+				return plugin
+			}
+		}
+
+		series := store.AppendSeries(state.Key(nodeStats.ClusterName, "disk_usage", id), map[string]float64{"used": float64(used)}, c.ForecastWindow)
+		slope, ok := state.Slope(series, "used")
+		if !ok || slope <= 0 {
+			details = append(details, fmt.Sprintf("%s: not growing (%d samples)", node.Name, len(series)))
+			continue
+		}
+
+		var eta float64
+		if limit > used {
+			eta = float64(limit-used) / slope / 3600
+		}
+
+		growing++
+		if growing == 1 || eta < minETA {
+			minETA = eta
+			minNode = node.Name
+		}
+
+		details = append(details, fmt.Sprintf("%s: growing %.0f bytes/h, %s in %.1fh", node.Name, slope*3600, targetDescription, eta))
+
+		etaPerfData := nagios.PerformanceData{
+			Label: fmt.Sprintf("%s_eta_hours", node.Name),
+			Value: fmt.Sprintf("%.1f", eta),
+			Warn:  fmt.Sprintf("%d:", c.WarningThreshold),
+			Crit:  fmt.Sprintf("%d:", c.CriticalThreshold),
+			Min:   "0",
+		}
+		pd = append(pd, etaPerfData)
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if growing == 0 {
+		plugin.ServiceOutput = "OK: No growing node disks"
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+		return plugin
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeBelow(c, minETA)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s %s in %.1fh",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), minNode, targetDescription, minETA)
+
+	return plugin
+}
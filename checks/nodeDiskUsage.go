@@ -13,6 +13,10 @@ import (
 )
 
 func CheckNodeDiskUsage(c *config.Config) *nagios.Plugin {
+	if c.Metric == "eta" {
+		return checkNodeDiskForecast(c)
+	}
+
 	plugin := nagios.NewPlugin()
 	defer plugin.ReturnCheckResults()
 
@@ -0,0 +1,102 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// recommendedMaxFileDescriptors is the file descriptor limit Elasticsearch
+// requires in production.
+const recommendedMaxFileDescriptors = 65535
+
+func CheckNodeFileDescriptors(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/process", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	nodeIDs := make([]string, 0, len(nodeStats.Nodes))
+	for id := range nodeStats.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	var pd []nagios.PerformanceData
+	var details, lowLimit []string
+	var maxPercent float64
+	var maxNode string
+	var checked int
+	for _, id := range nodeIDs {
+		node := nodeStats.Nodes[id]
+		if !nodeSelected(c, node.Name, node.IP) {
+			continue
+		}
+
+		// The limit isn't reported on Windows.
+		open := node.Process.OpenFileDescriptors
+		limit := node.Process.MaxFileDescriptors
+		if limit <= 0 {
+			details = append(details, fmt.Sprintf("%s: %d open file descriptors, no limit reported", node.Name, open))
+			continue
+		}
+
+		percent := 100 * float64(open) / float64(limit)
+		checked++
+		if checked == 1 || percent > maxPercent {
+			maxPercent = percent
+			maxNode = node.Name
+		}
+
+		detail := fmt.Sprintf("%s: %d of %d file descriptors open (%.1f%%)", node.Name, open, limit, percent)
+		if limit < recommendedMaxFileDescriptors {
+			lowLimit = append(lowLimit, node.Name)
+			detail += fmt.Sprintf(", limit below %d", recommendedMaxFileDescriptors)
+		}
+		details = append(details, detail)
+
+		fdsPerfData := nagios.PerformanceData{
+			Label:             fmt.Sprintf("%s_fds", node.Name),
+			Value:             fmt.Sprintf("%.2f", percent),
+			Warn:              fmt.Sprintf("%d", c.WarningThreshold),
+			Crit:              fmt.Sprintf("%d", c.CriticalThreshold),
+			Min:               "0",
+			Max:               "100",
+			UnitOfMeasurement: "%",
+		}
+		pd = append(pd, fdsPerfData)
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if checked == 0 {
+		plugin.ServiceOutput = "UNKNOWN: No file descriptor limits found"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, maxPercent)
+	if len(lowLimit) > 0 && plugin.ExitStatusCode == nagios.StateOKExitCode {
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("%s: File descriptor usage on node %s is %.2f%%",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), maxNode, maxPercent)
+	if len(lowLimit) > 0 {
+		plugin.ServiceOutput += fmt.Sprintf(", limit below %d on %s", recommendedMaxFileDescriptors, strings.Join(lowLimit, ", "))
+	}
+
+	return plugin
+}
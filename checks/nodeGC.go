@@ -0,0 +1,158 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"nagios-es/state"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+func CheckNodeGC(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "time_per_minute"
+	}
+
+	var description string
+	switch metric {
+	case "time_per_minute":
+		description = "GC time per minute"
+	case "avg_pause":
+		description = "Average GC pause"
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported GC metric %s", metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	collectors := helper.SplitList(c.Collectors)
+	if len(collectors) == 0 {
+		plugin.ServiceOutput = "UNKNOWN: At least one garbage collector is required"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/jvm", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	store, ok := openState(plugin, c)
+	if !ok {
+		return plugin
+	}
+	defer closeState(plugin, store)
+
+	nodeIDs := make([]string, 0, len(nodeStats.Nodes))
+	for id := range nodeStats.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var maxValue float64
+	var maxNode, maxCollector string
+	var checked int
+	for _, id := range nodeIDs {
+		node := nodeStats.Nodes[id]
+		if !nodeSelected(c, node.Name, node.IP) {
+			continue
+		}
+
+		for _, collector := range collectors {
+			gc, ok := node.JVM.GC.Collectors[collector]
+			if !ok {
+				continue
+			}
+
+			// Lifetime values are used until a previous sample is available.
+			count := float64(gc.CollectionCount)
+			millis := float64(gc.CollectionTimeInMillis)
+			minutes := float64(node.JVM.UptimeInMillis) / float64(time.Minute/time.Millisecond)
+
+			if store != nil {
+				key := state.Key(nodeStats.ClusterName, "gc", id, collector)
+				previous, _ := store.Get(key)
+				current := store.Put(key, map[string]float64{"count": count, "time": millis})
+
+				deltaCount, seconds, countOK := state.Delta(previous, current, "count")
+				deltaMillis, _, timeOK := state.Delta(previous, current, "time")
+				if countOK && timeOK {
+					count, millis, minutes = deltaCount, deltaMillis, seconds/60
+				}
+			}
+
+			var timePerMinute, avgPause float64
+			if minutes > 0 {
+				timePerMinute = millis / minutes
+			}
+			if count > 0 {
+				avgPause = millis / count
+			}
+
+			value := timePerMinute
+			if metric == "avg_pause" {
+				value = avgPause
+			}
+
+			checked++
+			if checked == 1 || value > maxValue {
+				maxValue = value
+				maxNode = node.Name
+				maxCollector = collector
+			}
+
+			details = append(details, fmt.Sprintf("%s %s: %.0f collections, %.1fms/min, %.1fms average pause", node.Name, collector, count, timePerMinute, avgPause))
+
+			timePerfData := nagios.PerformanceData{
+				Label:             fmt.Sprintf("%s_%s_time_per_minute", node.Name, collector),
+				Value:             fmt.Sprintf("%.2f", timePerMinute),
+				Min:               "0",
+				UnitOfMeasurement: "ms",
+			}
+			pausePerfData := nagios.PerformanceData{
+				Label:             fmt.Sprintf("%s_%s_avg_pause", node.Name, collector),
+				Value:             fmt.Sprintf("%.2f", avgPause),
+				Min:               "0",
+				UnitOfMeasurement: "ms",
+			}
+			if metric == "avg_pause" {
+				pausePerfData.Warn = fmt.Sprintf("%d", c.WarningThreshold)
+				pausePerfData.Crit = fmt.Sprintf("%d", c.CriticalThreshold)
+			} else {
+				timePerfData.Warn = fmt.Sprintf("%d", c.WarningThreshold)
+				timePerfData.Crit = fmt.Sprintf("%d", c.CriticalThreshold)
+			}
+			pd = append(pd, timePerfData, pausePerfData)
+		}
+	}
+
+	if checked == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: No garbage collectors found for %s", strings.Join(collectors, ","))
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, maxValue)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s of %s collector on node %s is %.2fms",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, maxCollector, maxNode, maxValue)
+
+	return plugin
+}
@@ -0,0 +1,145 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/helper"
+	"nagios-es/state"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+)
+
+func CheckNodeThreadPool(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	metric := c.Metric
+	if metric == "" {
+		metric = "rejected"
+	}
+
+	var description string
+	switch metric {
+	case "active":
+		description = "Active threads"
+	case "queue":
+		description = "Queue size"
+	case "rejected":
+		description = "Rejected tasks"
+	default:
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Unsupported thread pool metric %s", metric)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	pools := helper.SplitList(c.ThreadPools)
+	if len(pools) == 0 {
+		plugin.ServiceOutput = "UNKNOWN: At least one thread pool is required"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/thread_pool", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	// With a state file the rejected counter is turned into a rate since the last run.
+	var store *state.Store
+	if metric == "rejected" {
+		var ok bool
+		if store, ok = openState(plugin, c); !ok {
+			return plugin
+		}
+		defer closeState(plugin, store)
+
+		if store != nil {
+			description = "Rejected tasks/min"
+		}
+	}
+
+	nodeIDs := make([]string, 0, len(nodeStats.Nodes))
+	for id := range nodeStats.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	var pd []nagios.PerformanceData
+	var details []string
+	var maxValue float64
+	var maxNode, maxPool string
+	var checked int
+	for _, id := range nodeIDs {
+		node := nodeStats.Nodes[id]
+		if !nodeSelected(c, node.Name, node.IP) {
+			continue
+		}
+
+		for _, pool := range pools {
+			stats, ok := node.ThreadPool[pool]
+			if !ok {
+				continue
+			}
+
+			var value float64
+			switch metric {
+			case "active":
+				value = float64(stats.Active)
+			case "queue":
+				value = float64(stats.Queue)
+			case "rejected":
+				value = float64(stats.Rejected)
+			}
+
+			if store != nil {
+				key := state.Key(nodeStats.ClusterName, "thread_pool", id, pool)
+				previous, _ := store.Get(key)
+				current := store.Put(key, map[string]float64{"rejected": value})
+
+				// The first run and node restarts have no usable previous sample.
+				rate, _ := state.Rate(previous, current, "rejected")
+				value = rate * 60
+			}
+
+			checked++
+			if checked == 1 || value > maxValue {
+				maxValue = value
+				maxNode = node.Name
+				maxPool = pool
+			}
+
+			details = append(details, fmt.Sprintf("%s %s: active %d, queue %d, rejected %d", node.Name, pool, stats.Active, stats.Queue, stats.Rejected))
+
+			poolPerfData := nagios.PerformanceData{
+				Label: fmt.Sprintf("%s_%s_%s", node.Name, pool, metric),
+				Value: fmt.Sprintf("%.2f", value),
+				Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+				Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+				Min:   "0",
+			}
+			pd = append(pd, poolPerfData)
+		}
+	}
+
+	if checked == 0 {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: No thread pools found for %s", strings.Join(pools, ","))
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = gradeAbove(c, maxValue)
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s in %s pool on node %s is %.2f",
+		nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), description, maxPool, maxNode, maxValue)
+
+	return plugin
+}
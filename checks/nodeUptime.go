@@ -0,0 +1,143 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nagios-es/config"
+	"nagios-es/state"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// restartHistory is the time over which restarts are counted in the state file.
+const restartHistory = 24 * time.Hour
+
+// countRestarts counts the distinct start times in a series of samples. Start
+// times within a minute of each other are the same start, measured with some
+// jitter.
+func countRestarts(series []state.Sample) int {
+	var starts []float64
+	for _, sample := range series {
+		start := sample.Values["start"]
+		known := false
+		for _, s := range starts {
+			if math.Abs(s-start) < 60 {
+				known = true
+				break
+			}
+		}
+		if !known {
+			starts = append(starts, start)
+		}
+	}
+	return len(starts) - 1
+}
+
+func CheckNodeUptime(c *config.Config) *nagios.Plugin {
+	plugin := nagios.NewPlugin()
+	defer plugin.ReturnCheckResults()
+
+	var nodeStats ClusterNodesStatsResponse
+	if !getJSON(plugin, fmt.Sprintf("%s/_nodes/stats/jvm", c.ElasticsearchURL), &nodeStats) {
+		return plugin
+	}
+
+	store, ok := openState(plugin, c)
+	if !ok {
+		return plugin
+	}
+	defer closeState(plugin, store)
+
+	nodeIDs := make([]string, 0, len(nodeStats.Nodes))
+	for id := range nodeStats.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	now := time.Now()
+	var pd []nagios.PerformanceData
+	var details, restarted []string
+	var nodes, maxRestarts int
+	var maxRestartsNode string
+	for _, id := range nodeIDs {
+		node := nodeStats.Nodes[id]
+		if !nodeSelected(c, node.Name, node.IP) {
+			continue
+		}
+		nodes++
+
+		uptime := time.Duration(node.JVM.UptimeInMillis) * time.Millisecond
+		detail := fmt.Sprintf("%s: up %s", node.Name, uptime.Round(time.Second))
+		if uptime < c.RestartWindow {
+			restarted = append(restarted, node.Name)
+			detail += fmt.Sprintf(", restarted within %s", c.RestartWindow)
+		}
+
+		pd = append(pd, nagios.PerformanceData{
+			Label:             fmt.Sprintf("%s_uptime", node.Name),
+			Value:             fmt.Sprintf("%d", int64(uptime.Seconds())),
+			Min:               "0",
+			UnitOfMeasurement: "s",
+		})
+
+		if store != nil {
+			start := now.Add(-uptime)
+			series := store.AppendSeries(state.Key(nodeStats.ClusterName, "uptime", id), map[string]float64{"start": float64(start.Unix())}, restartHistory)
+
+			restarts := countRestarts(series)
+			if restarts > maxRestarts || maxRestartsNode == "" {
+				maxRestarts = restarts
+				maxRestartsNode = node.Name
+			}
+			detail += fmt.Sprintf(", %d restarts in the last day", restarts)
+
+			pd = append(pd, nagios.PerformanceData{
+				Label: fmt.Sprintf("%s_restarts", node.Name),
+				Value: fmt.Sprintf("%d", restarts),
+				Warn:  fmt.Sprintf("%d", c.WarningThreshold),
+				Crit:  fmt.Sprintf("%d", c.CriticalThreshold),
+				Min:   "0",
+			})
+		}
+
+		details = append(details, detail)
+	}
+	plugin.LongServiceOutput = strings.Join(details, nagios.CheckOutputEOL)
+
+	if nodes == 0 {
+		plugin.ServiceOutput = "UNKNOWN: No matching nodes"
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return plugin
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Printf("failed to add performance data metrics: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+
+	plugin.ExitStatusCode = nagios.StateOKExitCode
+	if store != nil {
+		plugin.ExitStatusCode = gradeAbove(c, float64(maxRestarts))
+	}
+	if len(restarted) > 0 && plugin.ExitStatusCode == nagios.StateOKExitCode {
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+	}
+
+	var summary []string
+	if len(restarted) > 0 {
+		summary = append(summary, fmt.Sprintf("%d nodes restarted within %s: %s", len(restarted), c.RestartWindow, strings.Join(restarted, ", ")))
+	} else {
+		summary = append(summary, fmt.Sprintf("No node of %d restarted within %s", nodes, c.RestartWindow))
+	}
+	if store != nil {
+		summary = append(summary, fmt.Sprintf("most restarts %s (%d in the last day)", maxRestartsNode, maxRestarts))
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("%s: %s", nagios.ExitCodeToStateLabel(plugin.ExitStatusCode), strings.Join(summary, ", "))
+
+	return plugin
+}
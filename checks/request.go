@@ -0,0 +1,97 @@
+package checks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// requestError describes a failed Elasticsearch request, with the response
+// body if Elasticsearch returned an error status.
+type requestError struct {
+	Message string
+	Body    string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+// fetchJSON fetches url and decodes the JSON response into v. It leaves the
+// plugin alone, so it suits optional requests whose failure isn't fatal.
+func fetchJSON(url string, v interface{}) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return &requestError{Message: "Failed to connect to Elasticsearch"}
+	}
+
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, v)
+}
+
+// getJSON fetches url and decodes the JSON response into v. On failure the
+// plugin is set to CRITICAL with a matching service output and false is
+// returned.
+func getJSON(plugin *nagios.Plugin, url string, v interface{}) bool {
+	return reportRequest(plugin, fetchJSON(url, v))
+}
+
+// postJSON sends body as JSON to url and decodes the JSON response into v,
+// failing the same way as getJSON.
+func postJSON(plugin *nagios.Plugin, url string, body interface{}, v interface{}) bool {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Failed to build request: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return false
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return reportRequest(plugin, &requestError{Message: "Failed to connect to Elasticsearch"})
+	}
+
+	defer resp.Body.Close()
+
+	return reportRequest(plugin, decodeResponse(resp, v))
+}
+
+// reportRequest sets the plugin to CRITICAL if the request failed.
+func reportRequest(plugin *nagios.Plugin, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	plugin.ServiceOutput = fmt.Sprintf("CRITICAL: %v", err)
+	plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+	var reqErr *requestError
+	if errors.As(err, &reqErr) && reqErr.Body != "" {
+		plugin.LongServiceOutput = reqErr.Body
+	}
+
+	return false
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &requestError{Message: "Failed to read response from Elasticsearch"}
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &requestError{Message: fmt.Sprintf("Elasticsearch returned %s", resp.Status), Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return &requestError{Message: "Failed to parse JSON response from Elasticsearch"}
+	}
+
+	return nil
+}
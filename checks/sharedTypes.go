@@ -1,20 +1,40 @@
 package checks
 
 type ClusterNodesStatsResponse struct {
-	Nodes map[string]NodeStats `json:"nodes"`
+	ClusterName string               `json:"cluster_name"`
+	Nodes       map[string]NodeStats `json:"nodes"`
 }
 
 type NodeStats struct {
-	Name string   `json:"name"`
-	IP   string   `json:"host"`
-	OS   OSStats  `json:"os"`
-	JVM  JVMStats `json:"jvm"`
-	FS   FSStats  `json:"fs"`
+	Name    string       `json:"name"`
+	IP      string       `json:"host"`
+	Roles   []string     `json:"roles"`
+	OS      OSStats      `json:"os"`
+	JVM     JVMStats     `json:"jvm"`
+	FS      FSStats      `json:"fs"`
+	Process ProcessStats `json:"process"`
+
+	ThreadPool map[string]ThreadPoolStats `json:"thread_pool"`
+	Breakers   map[string]BreakerStats    `json:"breakers"`
+	Indices    IndicesStats               `json:"indices"`
 }
 
 // JVMStats represents the JVM-related statistics for a node.
 type JVMStats struct {
-	Mem MemStats `json:"mem"`
+	Mem            MemStats `json:"mem"`
+	GC             GCStats  `json:"gc"`
+	UptimeInMillis int64    `json:"uptime_in_millis"`
+}
+
+// GCStats represents the garbage collection statistics for JVM.
+type GCStats struct {
+	Collectors map[string]GCCollectorStats `json:"collectors"`
+}
+
+// GCCollectorStats represents the statistics of a single garbage collector.
+type GCCollectorStats struct {
+	CollectionCount        int64 `json:"collection_count"`
+	CollectionTimeInMillis int64 `json:"collection_time_in_millis"`
 }
 
 // MemStats represents the memory-related statistics for JVM.
@@ -22,6 +42,12 @@ type MemStats struct {
 	HeapUsedPercent int `json:"heap_used_percent"`
 }
 
+// ProcessStats represents the process statistics for a node.
+type ProcessStats struct {
+	OpenFileDescriptors int64 `json:"open_file_descriptors"`
+	MaxFileDescriptors  int64 `json:"max_file_descriptors"`
+}
+
 type OSStats struct {
 	CPU CPUStats `json:"cpu"`
 }
@@ -31,6 +57,66 @@ type CPUStats struct {
 	Percent int `json:"percent"`
 }
 
+// ThreadPoolStats represents the statistics of a single thread pool.
+type ThreadPoolStats struct {
+	Threads   int64 `json:"threads"`
+	Queue     int64 `json:"queue"`
+	Active    int64 `json:"active"`
+	Rejected  int64 `json:"rejected"`
+	Largest   int64 `json:"largest"`
+	Completed int64 `json:"completed"`
+}
+
+// BreakerStats represents the statistics of a single circuit breaker.
+type BreakerStats struct {
+	LimitSizeInBytes     int64   `json:"limit_size_in_bytes"`
+	EstimatedSizeInBytes int64   `json:"estimated_size_in_bytes"`
+	Overhead             float64 `json:"overhead"`
+	Tripped              int64   `json:"tripped"`
+}
+
+// IndicesStatsResponse represents the response of the _stats API.
+type IndicesStatsResponse struct {
+	All     IndexStats            `json:"_all"`
+	Indices map[string]IndexStats `json:"indices"`
+}
+
+// IndexStats represents the statistics of a single index.
+type IndexStats struct {
+	Primaries IndicesStats `json:"primaries"`
+	Total     IndicesStats `json:"total"`
+}
+
+// IndicesStats represents the indexing and search statistics of a node or an index.
+type IndicesStats struct {
+	Docs     DocsStats     `json:"docs"`
+	Store    StoreStats    `json:"store"`
+	Indexing IndexingStats `json:"indexing"`
+	Search   SearchStats   `json:"search"`
+}
+
+// DocsStats represents the document counts.
+type DocsStats struct {
+	Count int64 `json:"count"`
+}
+
+// StoreStats represents the size on disk.
+type StoreStats struct {
+	SizeInBytes int64 `json:"size_in_bytes"`
+}
+
+// IndexingStats represents the indexing counters.
+type IndexingStats struct {
+	IndexTotal        int64 `json:"index_total"`
+	IndexTimeInMillis int64 `json:"index_time_in_millis"`
+}
+
+// SearchStats represents the search counters.
+type SearchStats struct {
+	QueryTotal        int64 `json:"query_total"`
+	QueryTimeInMillis int64 `json:"query_time_in_millis"`
+}
+
 // NodeFSStats represents the filesystem statistics for a specific node in the Elasticsearch cluster.
 type NodeFSStats struct {
 	FS FSStats `json:"fs"`
@@ -48,3 +134,122 @@ type TotalStats struct {
 	AvailableInBytes int64 `json:"available_in_bytes"`
 	UsedPercent      int
 }
+
+// ClusterNodesInfoResponse represents the response of the _nodes API.
+type ClusterNodesInfoResponse struct {
+	ClusterName string              `json:"cluster_name"`
+	Nodes       map[string]NodeInfo `json:"nodes"`
+}
+
+// NodeInfo represents the static information of a node in the Elasticsearch cluster.
+type NodeInfo struct {
+	Name        string   `json:"name"`
+	IP          string   `json:"host"`
+	Version     string   `json:"version"`
+	BuildFlavor string   `json:"build_flavor"`
+	Roles       []string `json:"roles"`
+	JVM         struct {
+		Version  string `json:"version"`
+		VMVendor string `json:"vm_vendor"`
+	} `json:"jvm"`
+}
+
+// RootResponse represents the response of the root endpoint.
+type RootResponse struct {
+	ClusterName string `json:"cluster_name"`
+	Version     struct {
+		Number       string `json:"number"`
+		Distribution string `json:"distribution"`
+		BuildFlavor  string `json:"build_flavor"`
+	} `json:"version"`
+}
+
+// IndexSettingsResponse represents the response of the index _settings API
+// with flat settings, per index.
+type IndexSettingsResponse map[string]struct {
+	Settings map[string]interface{} `json:"settings"`
+	Defaults map[string]interface{} `json:"defaults"`
+}
+
+// ClusterSettingsResponse represents the response of the _cluster/settings API with flat settings.
+type ClusterSettingsResponse struct {
+	Persistent map[string]interface{} `json:"persistent"`
+	Transient  map[string]interface{} `json:"transient"`
+	Defaults   map[string]interface{} `json:"defaults"`
+}
+
+// Setting returns the effective value of a setting, transient settings take
+// precedence over persistent settings and those over defaults.
+func (s ClusterSettingsResponse) Setting(name string) (string, bool) {
+	for _, settings := range []map[string]interface{}{s.Transient, s.Persistent, s.Defaults} {
+		if value, ok := settings[name].(string); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// CatAllocation represents a row of the _cat/allocation API, the unassigned
+// shards are reported with the node UNASSIGNED.
+type CatAllocation struct {
+	Shards      string `json:"shards"`
+	Node        string `json:"node"`
+	IP          string `json:"ip"`
+	DiskPercent string `json:"disk.percent"`
+}
+
+// CatShard represents a row of the _cat/shards API.
+type CatShard struct {
+	Index  string `json:"index"`
+	Shard  string `json:"shard"`
+	Prirep string `json:"prirep"`
+	State  string `json:"state"`
+	Docs   string `json:"docs"`
+	Store  string `json:"store"`
+	Node   string `json:"node"`
+}
+
+// CatIndex represents a row of the _cat/indices API.
+type CatIndex struct {
+	Health string `json:"health"`
+	Status string `json:"status"`
+	Index  string `json:"index"`
+	Pri    string `json:"pri"`
+	Rep    string `json:"rep"`
+}
+
+// CatRecovery represents a row of the _cat/recovery API with bytes=b and time=ms.
+type CatRecovery struct {
+	Index              string `json:"index"`
+	Shard              string `json:"shard"`
+	Time               string `json:"time"`
+	Type               string `json:"type"`
+	Stage              string `json:"stage"`
+	SourceNode         string `json:"source_node"`
+	TargetNode         string `json:"target_node"`
+	FilesPercent       string `json:"files_percent"`
+	BytesRecovered     string `json:"bytes_recovered"`
+	BytesTotal         string `json:"bytes_total"`
+	BytesPercent       string `json:"bytes_percent"`
+	TranslogOpsPercent string `json:"translog_ops_percent"`
+}
+
+// TasksResponse represents the response of the _tasks API grouped by node.
+type TasksResponse struct {
+	Nodes map[string]struct {
+		Name  string          `json:"name"`
+		Tasks map[string]Task `json:"tasks"`
+	} `json:"nodes"`
+}
+
+// Task represents a task of the _tasks API with detailed=true.
+type Task struct {
+	Node               string `json:"node"`
+	Action             string `json:"action"`
+	Description        string `json:"description"`
+	StartTimeInMillis  int64  `json:"start_time_in_millis"`
+	RunningTimeInNanos int64  `json:"running_time_in_nanos"`
+	Cancellable        bool   `json:"cancellable"`
+	Cancelled          bool   `json:"cancelled"`
+	ParentTaskID       string `json:"parent_task_id"`
+}
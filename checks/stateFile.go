@@ -0,0 +1,40 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"nagios-es/config"
+	"nagios-es/state"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// openState opens the configured state file. It returns a nil store if no
+// state file is configured, and false with the plugin set to UNKNOWN if the
+// file can't be opened.
+func openState(plugin *nagios.Plugin, c *config.Config) (*state.Store, bool) {
+	if c.StateFile == "" {
+		return nil, true
+	}
+
+	store, err := state.Open(c.StateFile, c.StateTTL)
+	if err != nil {
+		plugin.ServiceOutput = fmt.Sprintf("UNKNOWN: Can't open state file: %v", err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		return nil, false
+	}
+
+	return store, true
+}
+
+// closeState writes the state file back, recording any error on the plugin.
+func closeState(plugin *nagios.Plugin, store *state.Store) {
+	if store == nil {
+		return
+	}
+
+	if err := store.Close(); err != nil {
+		log.Printf("failed to save state file: %v\n", err)
+		plugin.Errors = append(plugin.Errors, err)
+	}
+}
@@ -2,25 +2,106 @@ package config
 
 import (
 	"flag"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+// ShardSizeRule holds the shard size limits for indices matching a pattern,
+// it is read from the shard_size list of the config file.
+type ShardSizeRule struct {
+	Pattern string `mapstructure:"pattern"`
+	Max     string `mapstructure:"max"`
+	Min     string `mapstructure:"min"`
+}
+
 type Config struct {
 	ElasticsearchURL  string
 	Check             string
 	NodeIP            string
 	NodeName          string
+	Roles             string
+	ThreadPools       string
+	Metric            string
+	StateFile         string
+	StateTTL          time.Duration
+	Breakers          string
+	Collectors        string
+	Index             string
+	Below             bool
+	ForecastTarget    string
+	ForecastWindow    time.Duration
+	TimestampField    string
+	SplitField        string
+	SplitSize         int
+	Query             string
+	QueryFile         string
+	QueryString       string
+	TimeRange         time.Duration
+	SampleSize        int
+	AggType           string
+	AggField          string
+	Percentile        string
+	Repository        string
+	PhaseTimeout      time.Duration
+	ActionTimeout     time.Duration
+	Tier              string
+	Statistic         string
+	MaxShardSize      string
+	MinShardSize      string
+	PrimariesOnly     bool
+	ShardSizeRules    []ShardSizeRule
+	Allow             string
+	IndexThresholds   string
+	Actions           string
+	MinVersion        string
+	RestartWindow     time.Duration
 	WarningThreshold  int
 	CriticalThreshold int
 }
 
 func LoadConfig() (*Config, error) {
+	flag.String("config", "", "Config file with default option values and per pattern rules")
 	flag.String("es_url", "", "Elasticsearch URL")
 	flag.String("check", "", "Check to perform")
 	flag.String("node_ip", "", "Node IP address for filtering")
 	flag.String("node_name", "", "Node Name for filtering")
+	flag.String("roles", "", "Role count limits, e.g. master=3:3,data_frozen=1")
+	flag.String("thread_pools", "write,search", "Thread pools to check, comma separated")
+	flag.String("metric", "", "Metric the thresholds apply to")
+	flag.String("state_file", "", "State file for rate calculations between runs")
+	flag.Duration("state_ttl", 24*time.Hour, "Time after which samples in the state file expire")
+	flag.String("breakers", "", "Circuit breakers to check, comma separated (default: all)")
+	flag.String("collectors", "young,old", "Garbage collectors to check, comma separated")
+	flag.String("index", "", "Index pattern, data stream or alias")
+	flag.Bool("below", false, "Alert when the value falls below the thresholds, for the indexing, search, query and agg checks")
+	flag.String("forecast_target", "full", "Disk forecast target: full or flood_stage")
+	flag.Duration("forecast_window", 24*time.Hour, "History used for the disk forecast")
+	flag.String("timestamp_field", "@timestamp", "Timestamp field of the documents")
+	flag.String("split_field", "", "Field to split the result into terms buckets")
+	flag.Int("split_size", 100, "Maximum number of terms buckets")
+	flag.String("query", "", "Query DSL as JSON")
+	flag.String("query_file", "", "File with the Query DSL as JSON")
+	flag.String("query_string", "", "Query in query string syntax, e.g. level:ERROR")
+	flag.Duration("time_range", 0, "Only documents newer than now minus this duration")
+	flag.Int("sample_size", 3, "Number of matching documents shown in the long output")
+	flag.String("agg_type", "avg", "Aggregation: avg, max, min, sum, percentiles or cardinality")
+	flag.String("agg_field", "", "Field to aggregate")
+	flag.String("percentile", "95", "Percentile for the percentiles aggregation")
+	flag.String("repository", "", "Snapshot repository")
+	flag.Duration("phase_timeout", 0, "Time after which an index is stuck in an ILM phase (default: disabled)")
+	flag.Duration("action_timeout", 24*time.Hour, "Time after which an index is stuck in an ILM action")
+	flag.String("tier", "", "Data tier role to compare nodes in, e.g. data_hot (default: all data nodes)")
+	flag.String("statistic", "spread", "Skew statistic: spread, stddev or ratio")
+	flag.String("max_shard_size", "50gb", "Shards larger than this are oversized")
+	flag.String("min_shard_size", "", "Shards smaller than this are undersized (default: disabled)")
+	flag.Bool("primaries_only", false, "Skip replica shards")
+	flag.String("allow", "", "Comma separated index patterns that are ignored")
+	flag.String("index_thresholds", "", "Warning and critical counts per category, e.g. red=:0,yellow=5:10")
+	flag.String("actions", "", "Comma separated task action patterns for the tasks check, e.g. *byquery,indices:data/write/reindex")
+	flag.String("min_version", "", "Minimum node version for the version check, e.g. 8.11.0")
+	flag.Duration("restart_window", time.Hour, "Time within which a node restart is reported by the uptime check")
 	flag.Int("w", 0, "Warning threshold")
 	flag.Int("c", 0, "Critical threshold")
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
@@ -30,6 +111,13 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if configFile := viper.GetString("config"); configFile != "" {
+		viper.SetConfigFile(configFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := viper.BindEnv("es_url"); err != nil {
 		return nil, err
 	}
@@ -46,6 +134,146 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if err := viper.BindEnv("roles"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("thread_pools"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("metric"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("state_file"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("breakers"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("collectors"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("state_ttl"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("index"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("below"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("forecast_target"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("forecast_window"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("timestamp_field"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("split_field"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("split_size"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("query"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("query_file"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("query_string"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("time_range"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("sample_size"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("agg_type"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("agg_field"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("percentile"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("repository"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("phase_timeout"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("action_timeout"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("tier"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("statistic"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("max_shard_size"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("min_shard_size"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("primaries_only"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("allow"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("index_thresholds"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("actions"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("min_version"); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("restart_window"); err != nil {
+		return nil, err
+	}
+
 	if err := viper.BindEnv("w"); err != nil {
 		return nil, err
 	}
@@ -61,9 +289,48 @@ func LoadConfig() (*Config, error) {
 		Check:             viper.GetString("check"),
 		NodeIP:            viper.GetString("node_ip"),
 		NodeName:          viper.GetString("node_name"),
+		Roles:             viper.GetString("roles"),
+		ThreadPools:       viper.GetString("thread_pools"),
+		Metric:            viper.GetString("metric"),
+		StateFile:         viper.GetString("state_file"),
+		StateTTL:          viper.GetDuration("state_ttl"),
+		Breakers:          viper.GetString("breakers"),
+		Collectors:        viper.GetString("collectors"),
+		Index:             viper.GetString("index"),
+		Below:             viper.GetBool("below"),
+		ForecastTarget:    viper.GetString("forecast_target"),
+		ForecastWindow:    viper.GetDuration("forecast_window"),
+		TimestampField:    viper.GetString("timestamp_field"),
+		SplitField:        viper.GetString("split_field"),
+		SplitSize:         viper.GetInt("split_size"),
+		Query:             viper.GetString("query"),
+		QueryFile:         viper.GetString("query_file"),
+		QueryString:       viper.GetString("query_string"),
+		TimeRange:         viper.GetDuration("time_range"),
+		SampleSize:        viper.GetInt("sample_size"),
+		AggType:           viper.GetString("agg_type"),
+		AggField:          viper.GetString("agg_field"),
+		Percentile:        viper.GetString("percentile"),
+		Repository:        viper.GetString("repository"),
+		PhaseTimeout:      viper.GetDuration("phase_timeout"),
+		ActionTimeout:     viper.GetDuration("action_timeout"),
+		Tier:              viper.GetString("tier"),
+		Statistic:         viper.GetString("statistic"),
+		MaxShardSize:      viper.GetString("max_shard_size"),
+		MinShardSize:      viper.GetString("min_shard_size"),
+		PrimariesOnly:     viper.GetBool("primaries_only"),
+		Allow:             viper.GetString("allow"),
+		IndexThresholds:   viper.GetString("index_thresholds"),
+		Actions:           viper.GetString("actions"),
+		MinVersion:        viper.GetString("min_version"),
+		RestartWindow:     viper.GetDuration("restart_window"),
 		WarningThreshold:  viper.GetInt("w"),
 		CriticalThreshold: viper.GetInt("c"),
 	}
 
+	if err := viper.UnmarshalKey("shard_size", &config.ShardSizeRules); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
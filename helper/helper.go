@@ -1,6 +1,11 @@
 package helper
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/atc0005/go-nagios"
 )
 
@@ -17,3 +22,93 @@ func ErrorUnknown(errString string) {
 func CalculateDiskUsagePercentage(total, free int64) int {
 	return int(100 * (total - free) / total)
 }
+
+// SplitList splits a comma separated list and drops empty items.
+func SplitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseByteSize parses an Elasticsearch byte size value such as 10gb or 512mb.
+func ParseByteSize(size string) (int64, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"pb", 1 << 50},
+		{"tb", 1 << 40},
+		{"gb", 1 << 30},
+		{"mb", 1 << 20},
+		{"kb", 1 << 10},
+		{"b", 1},
+	}
+
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(size, unit.suffix) {
+			size = strings.TrimSuffix(size, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(size), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", size)
+	}
+
+	return int64(value * multiplier), nil
+}
+
+// ParseTimeValue parses an Elasticsearch time value such as 30d or 12h.
+func ParseTimeValue(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time value %q", value)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time value %q", value)
+	}
+
+	return duration, nil
+}
+
+// CompareVersions compares two versions such as 8.11.0 or 7.17.3-SNAPSHOT
+// numerically and returns -1, 0 or 1. Qualifiers after a dash are ignored.
+func CompareVersions(a, b string) int {
+	partsA := strings.Split(strings.SplitN(strings.TrimSpace(a), "-", 2)[0], ".")
+	partsB := strings.Split(strings.SplitN(strings.TrimSpace(b), "-", 2)[0], ".")
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			y, _ = strconv.Atoi(partsB[i])
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -37,6 +37,56 @@ func main() {
 		plugin = checks.CheckNodeHeapMemory(cfg)
 	case "disk_usage":
 		plugin = checks.CheckNodeDiskUsage(cfg)
+	case "roles":
+		plugin = checks.CheckClusterNodeRoles(cfg)
+	case "thread_pool":
+		plugin = checks.CheckNodeThreadPool(cfg)
+	case "breakers":
+		plugin = checks.CheckNodeBreakers(cfg)
+	case "gc":
+		plugin = checks.CheckNodeGC(cfg)
+	case "indexing":
+		plugin = checks.CheckIndexing(cfg)
+	case "search":
+		plugin = checks.CheckSearch(cfg)
+	case "freshness":
+		plugin = checks.CheckIndexFreshness(cfg)
+	case "query":
+		plugin = checks.CheckIndexQuery(cfg)
+	case "agg":
+		plugin = checks.CheckIndexAggregation(cfg)
+	case "snapshot":
+		plugin = checks.CheckClusterSnapshot(cfg)
+	case "slm":
+		plugin = checks.CheckClusterSLM(cfg)
+	case "ilm":
+		plugin = checks.CheckClusterILM(cfg)
+	case "shard_limits":
+		plugin = checks.CheckClusterShardLimits(cfg)
+	case "balance":
+		plugin = checks.CheckClusterBalance(cfg)
+	case "shard_size":
+		plugin = checks.CheckIndexShardSize(cfg)
+	case "blocks":
+		plugin = checks.CheckIndexBlocks(cfg)
+	case "indices":
+		plugin = checks.CheckIndexInventory(cfg)
+	case "recovery":
+		plugin = checks.CheckClusterRecovery(cfg)
+	case "mapping":
+		plugin = checks.CheckIndexMapping(cfg)
+	case "tasks":
+		plugin = checks.CheckClusterTasks(cfg)
+	case "license":
+		plugin = checks.CheckClusterLicense(cfg)
+	case "certificates":
+		plugin = checks.CheckClusterCertificates(cfg)
+	case "version":
+		plugin = checks.CheckClusterVersion(cfg)
+	case "uptime":
+		plugin = checks.CheckNodeUptime(cfg)
+	case "fds":
+		plugin = checks.CheckNodeFileDescriptors(cfg)
 	default:
 		helper.ErrorUnknown(cfg.Check)
 	}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package state
+
+import "os"
+
+// Locking is only implemented on unix systems, where Nagios runs.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
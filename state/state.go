@@ -0,0 +1,217 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sample is a set of counter values recorded at a point in time.
+type Sample struct {
+	Time   time.Time          `json:"time"`
+	Values map[string]float64 `json:"values"`
+}
+
+// Store keeps samples between check runs in a JSON file. The file is locked
+// from Open until Close, so parallel Nagios workers don't overwrite each
+// other's samples.
+type Store struct {
+	path    string
+	ttl     time.Duration
+	lock    *os.File
+	Samples map[string]Sample   `json:"samples"`
+	Series  map[string][]Sample `json:"series"`
+}
+
+// Key builds a sample key from the cluster, check and node names, followed
+// by any further parts such as a thread pool name.
+func Key(cluster, check, node string, parts ...string) string {
+	return strings.Join(append([]string{cluster, check, node}, parts...), "/")
+}
+
+// Open locks and loads the store from path. A missing file results in an
+// empty store. Samples older than ttl are ignored and dropped on Close, a
+// zero ttl keeps samples forever.
+func Open(path string, ttl time.Duration) (*Store, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	store := &Store{path: path, ttl: ttl, lock: lock}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		store.unlock()
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, store); err != nil {
+			store.unlock()
+			return nil, err
+		}
+	}
+
+	if store.Samples == nil {
+		store.Samples = make(map[string]Sample)
+	}
+
+	if store.Series == nil {
+		store.Series = make(map[string][]Sample)
+	}
+
+	return store, nil
+}
+
+// Get returns the sample stored under key, unless it has expired.
+func (s *Store) Get(key string) (Sample, bool) {
+	sample, ok := s.Samples[key]
+	if !ok || s.expired(sample) {
+		return Sample{}, false
+	}
+	return sample, true
+}
+
+// Put stores values under key with the current time and returns the new sample.
+func (s *Store) Put(key string, values map[string]float64) Sample {
+	sample := Sample{Time: time.Now(), Values: values}
+	s.Samples[key] = sample
+	return sample
+}
+
+// AppendSeries adds values with the current time to the series stored under
+// key, drops samples older than window and returns the series.
+func (s *Store) AppendSeries(key string, values map[string]float64, window time.Duration) []Sample {
+	now := time.Now()
+
+	var series []Sample
+	for _, sample := range s.Series[key] {
+		if now.Sub(sample.Time) <= window && !s.expired(sample) {
+			series = append(series, sample)
+		}
+	}
+	series = append(series, Sample{Time: now, Values: values})
+
+	s.Series[key] = series
+	return series
+}
+
+// Close drops expired samples, writes the store back to its file and
+// releases the lock.
+func (s *Store) Close() error {
+	defer s.unlock()
+
+	for key, sample := range s.Samples {
+		if s.expired(sample) {
+			delete(s.Samples, key)
+		}
+	}
+
+	for key, series := range s.Series {
+		if len(series) == 0 || s.expired(series[len(series)-1]) {
+			delete(s.Series, key)
+		}
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *Store) expired(sample Sample) bool {
+	return s.ttl > 0 && time.Since(sample.Time) > s.ttl
+}
+
+func (s *Store) unlock() {
+	unlockFile(s.lock)
+	s.lock.Close()
+}
+
+// Delta returns how much the named counter grew between two samples and the
+// seconds between them. It returns false if there is no interval or the
+// counter went backwards, which happens when a node restarts.
+func Delta(previous, current Sample, name string) (delta, seconds float64, ok bool) {
+	seconds = current.Time.Sub(previous.Time).Seconds()
+	if previous.Time.IsZero() || seconds <= 0 {
+		return 0, 0, false
+	}
+
+	before, ok := previous.Values[name]
+	if !ok {
+		return 0, 0, false
+	}
+
+	delta = current.Values[name] - before
+	if delta < 0 {
+		return 0, 0, false
+	}
+
+	return delta, seconds, true
+}
+
+// Rate returns the per-second rate of the named counter between two samples.
+// See Delta for when ok is false.
+func Rate(previous, current Sample, name string) (float64, bool) {
+	delta, seconds, ok := Delta(previous, current, name)
+	if !ok {
+		return 0, false
+	}
+	return delta / seconds, true
+}
+
+// Slope fits a linear trend to the named value of a series and returns its
+// change per second. It returns false if the series has less than two
+// samples or they all share the same time.
+func Slope(series []Sample, name string) (float64, bool) {
+	if len(series) < 2 {
+		return 0, false
+	}
+
+	start := series[0].Time
+	var n, sumX, sumY, sumXY, sumXX float64
+	for _, sample := range series {
+		x := sample.Time.Sub(start).Seconds()
+		y := sample.Values[name]
+		n++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
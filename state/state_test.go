@@ -0,0 +1,154 @@
+package state
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenCloseRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	key := Key("cluster", "gc", "node", "young")
+	store.Put(key, map[string]float64{"count": 42})
+	store.AppendSeries(key, map[string]float64{"used": 7}, time.Hour)
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = Open(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+
+	sample, ok := store.Get(key)
+	if !ok {
+		t.Fatalf("Get(%q) found no sample", key)
+	}
+	if sample.Values["count"] != 42 {
+		t.Errorf("count = %v, want 42", sample.Values["count"])
+	}
+
+	if series := store.Series[key]; len(series) != 1 || series[0].Values["used"] != 7 {
+		t.Errorf("series = %v, want one sample with used 7", series)
+	}
+}
+
+func TestOpenMissingFile(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "missing.json"), 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+
+	if len(store.Samples) != 0 || len(store.Series) != 0 {
+		t.Errorf("store of a missing file isn't empty")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	store.Samples["old"] = Sample{Time: time.Now().Add(-2 * time.Hour), Values: map[string]float64{"count": 1}}
+	store.Series["old"] = []Sample{{Time: time.Now().Add(-2 * time.Hour)}}
+	store.Put("new", map[string]float64{"count": 2})
+
+	if _, ok := store.Get("old"); ok {
+		t.Errorf("Get() returned an expired sample")
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = Open(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.Samples["old"]; ok {
+		t.Errorf("expired sample wasn't dropped on Close")
+	}
+	if _, ok := store.Series["old"]; ok {
+		t.Errorf("expired series wasn't dropped on Close")
+	}
+	if _, ok := store.Get("new"); !ok {
+		t.Errorf("current sample was dropped on Close")
+	}
+}
+
+func TestDelta(t *testing.T) {
+	now := time.Now()
+	sample := func(offset time.Duration, value float64) Sample {
+		return Sample{Time: now.Add(offset), Values: map[string]float64{"count": value}}
+	}
+
+	tests := []struct {
+		name     string
+		previous Sample
+		current  Sample
+		want     float64
+		ok       bool
+	}{
+		{"growing counter", sample(-10*time.Second, 100), sample(0, 150), 50, true},
+		{"counter reset", sample(-10*time.Second, 100), sample(0, 20), 0, false},
+		{"no previous sample", Sample{}, sample(0, 150), 0, false},
+		{"no interval", sample(0, 100), sample(0, 150), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, seconds, ok := Delta(tt.previous, tt.current, "count")
+			if ok != tt.ok || delta != tt.want {
+				t.Errorf("Delta() = %v, %v, want %v, %v", delta, ok, tt.want, tt.ok)
+			}
+			if ok && seconds != 10 {
+				t.Errorf("Delta() seconds = %v, want 10", seconds)
+			}
+		})
+	}
+
+	if rate, ok := Rate(sample(-10*time.Second, 100), sample(0, 150), "count"); !ok || rate != 5 {
+		t.Errorf("Rate() = %v, %v, want 5, true", rate, ok)
+	}
+}
+
+func TestSlope(t *testing.T) {
+	start := time.Now()
+
+	var series []Sample
+	for i := 0; i < 5; i++ {
+		series = append(series, Sample{
+			Time:   start.Add(time.Duration(i) * time.Minute),
+			Values: map[string]float64{"used": 1000 + float64(i)*120},
+		})
+	}
+
+	slope, ok := Slope(series, "used")
+	if !ok || math.Abs(slope-2) > 1e-9 {
+		t.Errorf("Slope() = %v, %v, want 2, true", slope, ok)
+	}
+
+	if _, ok := Slope(series[:1], "used"); ok {
+		t.Errorf("Slope() of a single sample returned true")
+	}
+
+	same := []Sample{series[0], {Time: series[0].Time, Values: map[string]float64{"used": 5}}}
+	if _, ok := Slope(same, "used"); ok {
+		t.Errorf("Slope() of samples sharing the same time returned true")
+	}
+}